@@ -0,0 +1,12 @@
+package fixedlength
+
+import "reflect"
+
+// isArrayLayout reports whether the elements of the array v are laid out one after
+// the other, like a COBOL OCCURS table. Each element of a struct array takes the
+// layout of the struct, elements of scalar arrays the tag of the array field, which
+// must use `len` (or `pos` without a last position) for the elements not to overlap.
+// Byte arrays are left to the marshaler interfaces and converters.
+func isArrayLayout(v reflect.Value) bool {
+	return v.Kind() == reflect.Array && v.Type().Elem().Kind() != reflect.Uint8
+}
@@ -0,0 +1,45 @@
+package fixedlength
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type arrayLine struct {
+	SKU string `len:"3"`
+	Qty int    `len:"2"`
+}
+
+type arrayRecord struct {
+	_      struct{} `record:"prefix=0"`
+	ID     string   `range:"0,4"`
+	Lines  [2]arrayLine
+	Totals [3]int `len:"3"`
+	Flag   string `len:"1"`
+}
+
+func TestArrayRoundTrip(t *testing.T) {
+	in := arrayRecord{
+		ID:     "A001",
+		Lines:  [2]arrayLine{{SKU: "AAA", Qty: 1}, {SKU: "BBB", Qty: 22}},
+		Totals: [3]int{1, 20, 300},
+		Flag:   "Y",
+	}
+	res, err := Marshal(&in)
+	require.NoError(t, err)
+	require.Equal(t, "A001AAA01BBB22001020300Y", string(res))
+
+	var out arrayRecord
+	require.NoError(t, Unmarshal(res, &out))
+	require.Equal(t, in, out)
+}
+
+func TestArrayOverlap(t *testing.T) {
+	type record struct {
+		_     struct{}  `record:"prefix=0"`
+		Codes [2]string `range:"0,2"`
+	}
+	_, err := Marshal(&record{Codes: [2]string{"AA", "BB"}})
+	require.ErrorContains(t, err, "overlapping")
+}
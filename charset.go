@@ -0,0 +1,252 @@
+package fixedlength
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// UnmappableType decides what Encode does with characters the charset can't represent.
+type UnmappableType int
+
+var (
+	UnmappableTypeError   UnmappableType = 0
+	UnmappableTypeReplace UnmappableType = 1 // write the replacement character instead
+	UnmappableTypeSkip    UnmappableType = 2 // drop the character
+)
+
+// Charset converts record data between a legacy encoding and UTF-8.
+type Charset interface {
+	Name() string
+	// Decode converts data in the charset to a UTF-8 string.
+	Decode(data []byte) (string, error)
+	// Encode converts a UTF-8 string to the charset. Characters it can't represent
+	// are handled according to unmappable, replacement is used by UnmappableTypeReplace.
+	Encode(str string, unmappable UnmappableType, replacement byte) ([]byte, error)
+}
+
+// ErrUnmappableCharacter is returned when a character has no representation in the charset.
+type ErrUnmappableCharacter struct {
+	Charset string
+	Char    rune
+}
+
+func (e ErrUnmappableCharacter) Error() string {
+	return fmt.Sprintf("fixedlength: character %q can't be represented in %s", e.Char, e.Charset)
+}
+
+// singleByteCharset is a code page where bytes below 0x80 are ASCII
+// and the upper half is given by a table.
+type singleByteCharset struct {
+	name   string
+	upper  [128]rune
+	encode map[rune]byte
+}
+
+func newSingleByteCharset(name string, upper [128]rune) *singleByteCharset {
+	cs := &singleByteCharset{name: name, upper: upper, encode: make(map[rune]byte, 128)}
+	for i, r := range upper {
+		cs.encode[r] = byte(0x80 + i)
+	}
+	return cs
+}
+
+func (cs *singleByteCharset) Name() string {
+	return cs.name
+}
+
+func (cs *singleByteCharset) Decode(data []byte) (string, error) {
+	res := make([]rune, len(data))
+	for i, b := range data {
+		if b < 0x80 {
+			res[i] = rune(b)
+			continue
+		}
+		res[i] = cs.upper[b-0x80]
+	}
+	return string(res), nil
+}
+
+func (cs *singleByteCharset) Encode(str string, unmappable UnmappableType, replacement byte) ([]byte, error) {
+	res := make([]byte, 0, len(str))
+	for _, r := range str {
+		if r < 0x80 {
+			res = append(res, byte(r))
+			continue
+		}
+		if b, ok := cs.encode[r]; ok {
+			res = append(res, b)
+			continue
+		}
+
+		b, ok, err := unmappableByte(cs.name, r, unmappable, replacement)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			res = append(res, b)
+		}
+	}
+	return res, nil
+}
+
+// unmappableByte applies the unmappable policy to r, ok is false when r is skipped.
+func unmappableByte(charset string, r rune, unmappable UnmappableType, replacement byte) (byte, bool, error) {
+	switch unmappable {
+	case UnmappableTypeReplace:
+		return replacement, true, nil
+	case UnmappableTypeSkip:
+		return 0, false, nil
+	}
+	return 0, false, ErrUnmappableCharacter{Charset: charset, Char: r}
+}
+
+var (
+	CharsetISO88591    Charset = newSingleByteCharset("ISO-8859-1", latin1Upper())
+	CharsetWindows1252 Charset = newSingleByteCharset("Windows-1252", windows1252Upper)
+	CharsetCP437       Charset = newSingleByteCharset("CP437", cp437Upper)
+	CharsetCP850       Charset = newSingleByteCharset("CP850", cp850Upper)
+)
+
+var (
+	charsetsMu sync.RWMutex
+	charsets   = map[string]Charset{}
+)
+
+// RegisterCharset makes a charset available to CharsetByName and the charset tag.
+// Names are case insensitive, registered charsets take precedence over built-in ones.
+func RegisterCharset(name string, cs Charset) {
+	charsetsMu.Lock()
+	defer charsetsMu.Unlock()
+	charsets[strings.ToUpper(name)] = cs
+}
+
+// CharsetByName returns a registered or built-in charset by one of its common names.
+func CharsetByName(name string) (Charset, bool) {
+	charsetsMu.RLock()
+	cs, ok := charsets[strings.ToUpper(name)]
+	charsetsMu.RUnlock()
+	if ok {
+		return cs, true
+	}
+
+	switch strings.ToUpper(name) {
+	case "ISO-8859-1", "ISO8859-1", "LATIN1", "LATIN-1":
+		return CharsetISO88591, true
+	case "WINDOWS-1252", "CP1252":
+		return CharsetWindows1252, true
+	case "CP437", "IBM437":
+		return CharsetCP437, true
+	case "CP850", "IBM850":
+		return CharsetCP850, true
+	case "SHIFT-JIS", "SHIFT_JIS", "SJIS":
+		return CharsetShiftJIS, true
+	}
+	return nil, false
+}
+
+func latin1Upper() [128]rune {
+	var res [128]rune
+	for i := range res {
+		res[i] = rune(0x80 + i)
+	}
+	return res
+}
+
+// fieldCharset returns the charset of a field: the charset tag, or the codec charset.
+func (c *Codec) fieldCharset(t tag) Charset {
+	if t.charset != nil {
+		return t.charset
+	}
+	return c.config.Charset
+}
+
+// decodeText converts data in cs to UTF-8, a nil charset means UTF-8.
+func decodeText(cs Charset, data []byte) (string, error) {
+	if cs == nil {
+		return string(data), nil
+	}
+	return cs.Decode(data)
+}
+
+// encodeText converts UTF-8 text to cs with the codec unmappable policy.
+func (c *Codec) encodeText(cs Charset, str string) (string, error) {
+	if cs == nil {
+		return str, nil
+	}
+	res, err := cs.Encode(str, c.config.Unmappable, c.config.Replacement)
+	return string(res), err
+}
+
+// dropUnmappable removes the characters the record charset can't represent when the
+// codec skips them, so fields laid out in runes or columns keep their width once encoded.
+func (c *Codec) dropUnmappable(str string) string {
+	cs := c.config.Charset
+	if cs == nil || c.config.Unmappable != UnmappableTypeSkip {
+		return str
+	}
+	return strings.Map(func(r rune) rune {
+		if _, err := cs.Encode(string(r), UnmappableTypeError, 0); err != nil {
+			return -1
+		}
+		return r
+	}, str)
+}
+
+// windows1252Upper maps 0x80-0xFF, undefined bytes map to the C1 control with the same value.
+var windows1252Upper = [128]rune{
+	0x20AC, 0x0081, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008D, 0x017D, 0x008F,
+	0x0090, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0x009D, 0x017E, 0x0178,
+	0x00A0, 0x00A1, 0x00A2, 0x00A3, 0x00A4, 0x00A5, 0x00A6, 0x00A7,
+	0x00A8, 0x00A9, 0x00AA, 0x00AB, 0x00AC, 0x00AD, 0x00AE, 0x00AF,
+	0x00B0, 0x00B1, 0x00B2, 0x00B3, 0x00B4, 0x00B5, 0x00B6, 0x00B7,
+	0x00B8, 0x00B9, 0x00BA, 0x00BB, 0x00BC, 0x00BD, 0x00BE, 0x00BF,
+	0x00C0, 0x00C1, 0x00C2, 0x00C3, 0x00C4, 0x00C5, 0x00C6, 0x00C7,
+	0x00C8, 0x00C9, 0x00CA, 0x00CB, 0x00CC, 0x00CD, 0x00CE, 0x00CF,
+	0x00D0, 0x00D1, 0x00D2, 0x00D3, 0x00D4, 0x00D5, 0x00D6, 0x00D7,
+	0x00D8, 0x00D9, 0x00DA, 0x00DB, 0x00DC, 0x00DD, 0x00DE, 0x00DF,
+	0x00E0, 0x00E1, 0x00E2, 0x00E3, 0x00E4, 0x00E5, 0x00E6, 0x00E7,
+	0x00E8, 0x00E9, 0x00EA, 0x00EB, 0x00EC, 0x00ED, 0x00EE, 0x00EF,
+	0x00F0, 0x00F1, 0x00F2, 0x00F3, 0x00F4, 0x00F5, 0x00F6, 0x00F7,
+	0x00F8, 0x00F9, 0x00FA, 0x00FB, 0x00FC, 0x00FD, 0x00FE, 0x00FF,
+}
+
+var cp437Upper = [128]rune{
+	0x00C7, 0x00FC, 0x00E9, 0x00E2, 0x00E4, 0x00E0, 0x00E5, 0x00E7,
+	0x00EA, 0x00EB, 0x00E8, 0x00EF, 0x00EE, 0x00EC, 0x00C4, 0x00C5,
+	0x00C9, 0x00E6, 0x00C6, 0x00F4, 0x00F6, 0x00F2, 0x00FB, 0x00F9,
+	0x00FF, 0x00D6, 0x00DC, 0x00A2, 0x00A3, 0x00A5, 0x20A7, 0x0192,
+	0x00E1, 0x00ED, 0x00F3, 0x00FA, 0x00F1, 0x00D1, 0x00AA, 0x00BA,
+	0x00BF, 0x2310, 0x00AC, 0x00BD, 0x00BC, 0x00A1, 0x00AB, 0x00BB,
+	0x2591, 0x2592, 0x2593, 0x2502, 0x2524, 0x2561, 0x2562, 0x2556,
+	0x2555, 0x2563, 0x2551, 0x2557, 0x255D, 0x255C, 0x255B, 0x2510,
+	0x2514, 0x2534, 0x252C, 0x251C, 0x2500, 0x253C, 0x255E, 0x255F,
+	0x255A, 0x2554, 0x2569, 0x2566, 0x2560, 0x2550, 0x256C, 0x2567,
+	0x2568, 0x2564, 0x2565, 0x2559, 0x2558, 0x2552, 0x2553, 0x256B,
+	0x256A, 0x2518, 0x250C, 0x2588, 0x2584, 0x258C, 0x2590, 0x2580,
+	0x03B1, 0x00DF, 0x0393, 0x03C0, 0x03A3, 0x03C3, 0x00B5, 0x03C4,
+	0x03A6, 0x0398, 0x03A9, 0x03B4, 0x221E, 0x03C6, 0x03B5, 0x2229,
+	0x2261, 0x00B1, 0x2265, 0x2264, 0x2320, 0x2321, 0x00F7, 0x2248,
+	0x00B0, 0x2219, 0x00B7, 0x221A, 0x207F, 0x00B2, 0x25A0, 0x00A0,
+}
+
+var cp850Upper = [128]rune{
+	0x00C7, 0x00FC, 0x00E9, 0x00E2, 0x00E4, 0x00E0, 0x00E5, 0x00E7,
+	0x00EA, 0x00EB, 0x00E8, 0x00EF, 0x00EE, 0x00EC, 0x00C4, 0x00C5,
+	0x00C9, 0x00E6, 0x00C6, 0x00F4, 0x00F6, 0x00F2, 0x00FB, 0x00F9,
+	0x00FF, 0x00D6, 0x00DC, 0x00F8, 0x00A3, 0x00D8, 0x00D7, 0x0192,
+	0x00E1, 0x00ED, 0x00F3, 0x00FA, 0x00F1, 0x00D1, 0x00AA, 0x00BA,
+	0x00BF, 0x00AE, 0x00AC, 0x00BD, 0x00BC, 0x00A1, 0x00AB, 0x00BB,
+	0x2591, 0x2592, 0x2593, 0x2502, 0x2524, 0x00C1, 0x00C2, 0x00C0,
+	0x00A9, 0x2563, 0x2551, 0x2557, 0x255D, 0x00A2, 0x00A5, 0x2510,
+	0x2514, 0x2534, 0x252C, 0x251C, 0x2500, 0x253C, 0x00E3, 0x00C3,
+	0x255A, 0x2554, 0x2569, 0x2566, 0x2560, 0x2550, 0x256C, 0x00A4,
+	0x00F0, 0x00D0, 0x00CA, 0x00CB, 0x00C8, 0x0131, 0x00CD, 0x00CE,
+	0x00CF, 0x2518, 0x250C, 0x2588, 0x2584, 0x00A6, 0x00CC, 0x2580,
+	0x00D3, 0x00DF, 0x00D4, 0x00D2, 0x00F5, 0x00D5, 0x00B5, 0x00FE,
+	0x00DE, 0x00DA, 0x00DB, 0x00D9, 0x00FD, 0x00DD, 0x00AF, 0x00B4,
+	0x00AD, 0x00B1, 0x2017, 0x00BE, 0x00B6, 0x00A7, 0x00F7, 0x00B8,
+	0x00B0, 0x00A8, 0x00B7, 0x00B9, 0x00B3, 0x00B2, 0x25A0, 0x00A0,
+}
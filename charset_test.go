@@ -0,0 +1,109 @@
+package fixedlength
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSingleByteCharsets(t *testing.T) {
+	tests := []struct {
+		name    string
+		charset Charset
+		data    []byte
+		text    string
+	}{
+		{name: "latin1", charset: CharsetISO88591, data: []byte{'M', 0xFC, 'l', 'l', 'e', 'r'}, text: "Müller"},
+		{name: "windows-1252", charset: CharsetWindows1252, data: []byte{0x80, ' ', 0xC4, 0x9C}, text: "€ Äœ"},
+		{name: "cp437", charset: CharsetCP437, data: []byte{'M', 0x81, 'l', 0xE1}, text: "Mülß"},
+		{name: "cp850", charset: CharsetCP850, data: []byte{0x8E, 0x99, 0x9A, 0xD5}, text: "ÄÖÜı"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, err := tt.charset.Decode(tt.data)
+			require.NoError(t, err)
+			require.Equal(t, tt.text, text)
+
+			data, err := tt.charset.Encode(tt.text, UnmappableTypeError, '?')
+			require.NoError(t, err)
+			require.Equal(t, tt.data, data)
+		})
+	}
+}
+
+func TestCharsetUnmappable(t *testing.T) {
+	_, err := CharsetISO88591.Encode("a€b", UnmappableTypeError, '?')
+	require.ErrorAs(t, err, &ErrUnmappableCharacter{})
+
+	data, err := CharsetISO88591.Encode("a€b", UnmappableTypeReplace, '?')
+	require.NoError(t, err)
+	require.Equal(t, []byte("a?b"), data)
+
+	data, err = CharsetISO88591.Encode("a€b", UnmappableTypeSkip, '?')
+	require.NoError(t, err)
+	require.Equal(t, []byte("ab"), data)
+}
+
+func TestCharsetByName(t *testing.T) {
+	cs, ok := CharsetByName("latin1")
+	require.True(t, ok)
+	require.Equal(t, "ISO-8859-1", cs.Name())
+
+	cs, ok = CharsetByName("cp1252")
+	require.True(t, ok)
+	require.Equal(t, "Windows-1252", cs.Name())
+
+	_, ok = CharsetByName("ebcdic")
+	require.False(t, ok)
+}
+
+func TestCodecCharset(t *testing.T) {
+	type rec struct {
+		Name string `range:"0,7"`
+		City string `range:"7,12"`
+	}
+
+	for _, unit := range []PositionUnit{PositionUnitRune, PositionUnitByte} {
+		cfg := DefaultConfig()
+		cfg.TypePrefixLength = 0
+		cfg.Charset = CharsetWindows1252
+		cfg.PositionUnit = unit
+		c := NewCodec(cfg)
+
+		res, err := c.Marshal(&rec{Name: "Müller", City: "Köln"})
+		require.NoError(t, err)
+		require.Equal(t, []byte("M\xFCller K\xF6ln "), res)
+
+		var v rec
+		require.NoError(t, c.Unmarshal(res, &v))
+		require.Equal(t, rec{Name: "Müller", City: "Köln"}, v)
+	}
+
+	cfg := DefaultConfig()
+	cfg.TypePrefixLength = 0
+	cfg.Charset = CharsetISO88591
+	cfg.Unmappable = UnmappableTypeReplace
+	res, err := NewCodec(cfg).Marshal(&rec{Name: "€100", City: "Köln"})
+	require.NoError(t, err)
+	require.Equal(t, []byte("?100   K\xF6ln "), res)
+}
+
+func TestCodecCharsetSkip(t *testing.T) {
+	type rec struct {
+		Name string `range:"0,5"`
+		Tail string `range:"5,7"`
+	}
+
+	for _, unit := range []PositionUnit{PositionUnitRune, PositionUnitColumn, PositionUnitByte} {
+		cfg := DefaultConfig()
+		cfg.TypePrefixLength = 0
+		cfg.Charset = CharsetISO88591
+		cfg.Unmappable = UnmappableTypeSkip
+		cfg.PositionUnit = unit
+
+		res, err := NewCodec(cfg).Marshal(&rec{Name: "a€b", Tail: "XY"})
+		require.NoError(t, err)
+		require.Equal(t, []byte("ab   XY"), res)
+	}
+}
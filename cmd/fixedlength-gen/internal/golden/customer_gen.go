@@ -0,0 +1,52 @@
+// Code generated by fixedlength-gen from customer.cpy. DO NOT EDIT.
+
+package golden
+
+// Customer is the record CUSTOMER-RECORD of customer.cpy.
+type Customer struct {
+	_ struct{} `record:"length=50,prefix=0"`
+	// CustID is CUST-ID, PIC 9(6).
+	CustID int64 `range:"0,6" align:"right" sign:"none"`
+	// CustStatus is CUST-STATUS, PIC X.
+	// STATUS-ACTIVE: "A".
+	CustStatus string   `range:"6,7"`
+	_          struct{} `range:"7,9" flags:"filler"`
+	// CustBalance is CUST-BALANCE, PIC S9(5)V99 COMP-3.
+	// It holds the value times 100.
+	CustBalance int64 `range:"9,13" align:"right" usage:"comp-3"`
+	// CustOrders is CUST-ORDERS, PIC S9(4) COMP.
+	CustOrders int64 `range:"13,15" align:"right" usage:"comp"`
+	// CustCredit is CUST-CREDIT, PIC S9(3)V9.
+	// It holds the value times 10.
+	CustCredit int64 `range:"15,20" align:"right" sign:"leading"`
+	// CustSince is CUST-SINCE.
+	CustSince CustSince
+	// CustSinceN is CUST-SINCE-N, PIC 9(6).
+	// It redefines CUST-SINCE.
+	CustSinceN int64 `range:"20,26" flags:"optional" align:"right" sign:"none" redefines:"CustSince"`
+	// CustPhones is CUST-PHONES.
+	// It occurs 2 times.
+	CustPhones [2]CustPhones
+	// CustCodes is CUST-CODES, PIC X(2).
+	// It occurs 3 times.
+	CustCodes [3]string `len:"2"`
+	// CustRegion is CUST-REGION, PIC X(2).
+	CustRegion string `range:"48,50" flags:"optional"`
+	// CustRegionN is CUST-REGION-N, PIC 9(2).
+	// It redefines CUST-REGION.
+	CustRegionN int64 `range:"48,50" flags:"optional" align:"right" sign:"none" redefines:"CustRegion"`
+}
+
+// CustSince is the group CUST-SINCE.
+type CustSince struct {
+	// SinceYear is SINCE-YEAR, PIC 9(4).
+	SinceYear int64 `range:"20,24" flags:"optional" align:"right" sign:"none"`
+	// SinceMonth is SINCE-MONTH, PIC 99.
+	SinceMonth int64 `range:"24,26" flags:"optional" align:"right" sign:"none"`
+}
+
+// CustPhones is the group CUST-PHONES.
+type CustPhones struct {
+	// PhoneNumber is PHONE-NUMBER, PIC X(8).
+	PhoneNumber string `len:"8"`
+}
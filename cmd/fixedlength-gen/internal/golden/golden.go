@@ -0,0 +1,7 @@
+// Package golden holds structs generated by fixedlength-gen, the generator tests
+// check they are up to date and round trip records through them.
+package golden
+
+//go:generate go run github.com/sadensmol/fixedlength/cmd/fixedlength-gen -in customer.cpy -type Customer -out customer_gen.go
+//go:generate go run github.com/sadensmol/fixedlength/cmd/fixedlength-gen -in order.cpy -out order_gen.go
+//go:generate go run github.com/sadensmol/fixedlength/cmd/fixedlength-gen -in payment.yaml -type Payment -out payment_gen.go
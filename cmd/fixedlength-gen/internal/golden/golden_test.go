@@ -0,0 +1,71 @@
+package golden
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sadensmol/fixedlength"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomer(t *testing.T) {
+	cfg := fixedlength.DefaultConfig()
+	cfg.PositionUnit = fixedlength.PositionUnitByte
+	codec := fixedlength.NewCodec(cfg)
+
+	in := Customer{
+		CustID:      42,
+		CustStatus:  "A",
+		CustBalance: -1234567,
+		CustOrders:  300,
+		CustCredit:  -125,
+		CustSince:   CustSince{SinceYear: 2024, SinceMonth: 7},
+		CustPhones:  [2]CustPhones{{PhoneNumber: "5550100"}, {PhoneNumber: "5550199"}},
+		CustCodes:   [3]string{"AB", "CD", "EF"},
+		CustRegion:  "NE",
+	}
+	res, err := codec.Marshal(&in)
+	require.NoError(t, err)
+	want := "000042A  \x12\x34\x56\x7D\x01\x2C-0125202407" + "5550100 5550199 ABCDEFNE"
+	require.Equal(t, want, string(res))
+
+	var out Customer
+	require.NoError(t, codec.Unmarshal(res, &out))
+	in.CustSinceN = 202407 // the view is decoded too
+	require.Equal(t, in, out)
+
+	// both views agree, so the decoded record encodes back
+	again, err := codec.Marshal(&out)
+	require.NoError(t, err)
+	require.Equal(t, want, string(again))
+
+	// the numeric view of the region doesn't decode, it is left zero
+	require.Zero(t, out.CustRegionN)
+	numeric := []byte(want)
+	copy(numeric[48:], "12")
+	require.NoError(t, codec.Unmarshal(numeric, &out))
+	require.Equal(t, "12", out.CustRegion)
+	require.Equal(t, int64(12), out.CustRegionN)
+}
+
+func TestOrder(t *testing.T) {
+	var out Order
+	require.NoError(t, fixedlength.Unmarshal([]byte("ABCDEFGHIJ0102031007"), &out))
+	require.Equal(t, Order{OrderID: "ABCDEFGHIJ", ItemCount: 1, Items: [3]Items{{Qty: 2, Price: 31007}}}, out)
+
+	// every occurrence is written
+	res, err := fixedlength.Marshal(&out)
+	require.NoError(t, err)
+	require.Equal(t, "ABCDEFGHIJ0102031007"+"00000000"+"00000000", string(res))
+}
+
+func TestPayment(t *testing.T) {
+	in := Payment{AccountID: "ACC001", Amount: 12.5, Booked: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+	res, err := fixedlength.Marshal(&in)
+	require.NoError(t, err)
+	require.Equal(t, "6ACC0010125020240301", string(res))
+
+	var out Payment
+	require.NoError(t, fixedlength.Unmarshal(res, &out))
+	require.Equal(t, in, out)
+}
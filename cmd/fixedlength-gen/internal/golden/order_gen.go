@@ -0,0 +1,24 @@
+// Code generated by fixedlength-gen from order.cpy. DO NOT EDIT.
+
+package golden
+
+// Order is the record ORDER of order.cpy.
+type Order struct {
+	_ struct{} `record:"prefix=0"`
+	// OrderID is ORDER-ID, PIC X(10).
+	OrderID string `range:"0,10"`
+	// ItemCount is ITEM-COUNT, PIC 9(2).
+	ItemCount int64 `range:"10,12" align:"right" sign:"none"`
+	// Items is ITEMS.
+	// It occurs 1 to 3 times depending on ITEM-COUNT, occurrences missing from shorter records decode as zero.
+	Items [3]Items
+}
+
+// Items is the group ITEMS.
+type Items struct {
+	// Qty is QTY, PIC 9(2).
+	Qty int64 `len:"2" flags:"optional" align:"right" sign:"none"`
+	// Price is PRICE, PIC 9(4)V99.
+	// It holds the value times 100.
+	Price int64 `len:"6" flags:"optional" align:"right" sign:"none"`
+}
@@ -0,0 +1,18 @@
+// Code generated by fixedlength-gen from payment.yaml. DO NOT EDIT.
+
+package golden
+
+import "time"
+
+// Payment is the record of payment.yaml.
+type Payment struct {
+	_ struct{} `record:"length=20,prefix=0"`
+	// The record type.
+	_ struct{} `range:"0,1" const:"6"`
+	// AccountID is account-id.
+	AccountID string `range:"1,7"`
+	// Amount is amount.
+	Amount float64 `range:"7,12" align:"right" decimals:"2"`
+	// Booked is booked.
+	Booked time.Time `range:"12,20" format:"20060102"`
+}
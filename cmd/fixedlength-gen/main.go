@@ -0,0 +1,524 @@
+// Command fixedlength-gen generates Go structs tagged for the fixedlength package
+// from a COBOL copybook or a JSON or YAML schema file, for use with go generate:
+//
+//	//go:generate go run github.com/sadensmol/fixedlength/cmd/fixedlength-gen -in customer.cpy -type Customer -out customer_gen.go
+//
+// Files ending in .json, .yaml or .yml are read with fixedlength.LoadSchema, others
+// are parsed with fixedlength.ParseCopybook.
+//
+// Field names are derived from the original names (CUST-ID becomes CustID) and are
+// stable across runs, duplicates get a numeric suffix. Each field keeps its original
+// name in its doc comment. Copybook numbers become int64, those with decimals scaled
+// by their implied decimal point (PIC 9(5)V99 holds 123.45 as 12345), so amounts
+// aren't rounded through a float. Schema numbers follow their type, schema times
+// become time.Time. SIGN SEPARATE items get a `sign:"leading"` or
+// `sign:"trailing"` tag, unsigned ones `sign:"none"`. Copybook groups become nested
+// struct types, OCCURS tables arrays, FILLER items filler fields and REDEFINES views
+// fields tagged with `redefines`; the redefined items and their views are flagged
+// optional, so records decode whichever member they hold. OCCURS DEPENDING ON tables
+// are sized for their maximum and their elements flagged optional, so records ending
+// after the last occurrence decode; Marshal writes every occurrence. Level 66 (RENAMES) entries are
+// left out.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/sadensmol/fixedlength"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "fixedlength-gen:", err)
+		os.Exit(1)
+	}
+}
+
+type options struct {
+	in       string
+	out      string
+	typeName string
+	pkg      string
+	record   string
+}
+
+func run(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("fixedlength-gen", flag.ContinueOnError)
+	var opts options
+	fs.StringVar(&opts.in, "in", "", "copybook or schema file (required)")
+	fs.StringVar(&opts.out, "out", "", "output file, standard output if empty")
+	fs.StringVar(&opts.typeName, "type", "", "name of the record type, derived from the record name if empty")
+	fs.StringVar(&opts.pkg, "package", os.Getenv("GOPACKAGE"), "package of the generated file, $GOPACKAGE by default")
+	fs.StringVar(&opts.record, "record", "", "copybook record to generate, the only one if empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if opts.in == "" {
+		return errors.New("missing -in")
+	}
+	if opts.pkg == "" {
+		opts.pkg = "main"
+	}
+
+	src, err := os.ReadFile(opts.in)
+	if err != nil {
+		return err
+	}
+	code, err := generate(filepath.Base(opts.in), src, opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.out == "" {
+		_, err = stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(opts.out, code, 0o644)
+}
+
+// generate returns the Go source of the types describing the layout in src.
+func generate(name string, src []byte, opts options) ([]byte, error) {
+	g := &generator{typeNames: map[string]bool{}}
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json", ".yaml", ".yml":
+		s, err := fixedlength.LoadSchema(bytes.NewReader(src))
+		if err != nil {
+			return nil, err
+		}
+		g.schema(s, opts.typeName, name)
+	default:
+		cb, err := fixedlength.ParseCopybook(bytes.NewReader(src))
+		if err != nil {
+			return nil, err
+		}
+		if err := g.copybook(cb, opts.record, opts.typeName, name); err != nil {
+			return nil, err
+		}
+	}
+
+	return g.render(name, opts.pkg)
+}
+
+type generator struct {
+	types     []*structType
+	typeNames map[string]bool
+	usesTime  bool
+}
+
+type structType struct {
+	name   string
+	doc    string
+	fields []goField
+	used   map[string]bool
+	names  map[string]string // original name to field name, for the redefines tags
+}
+
+type goField struct {
+	name string
+	typ  string
+	tags []string
+	doc  []string
+}
+
+// newType declares a struct type, named after name or, if it's taken, after
+// the enclosing type and name.
+func (g *generator) newType(name string, outer string, doc func(name string) string) *structType {
+	typeName := name
+	if g.typeNames[typeName] {
+		typeName = outer + name
+	}
+	for i := 2; g.typeNames[typeName]; i++ {
+		typeName = fmt.Sprintf("%s%s%d", outer, name, i)
+	}
+	g.typeNames[typeName] = true
+
+	st := &structType{name: typeName, doc: doc(typeName), used: map[string]bool{}, names: map[string]string{}}
+	g.types = append(g.types, st)
+	return st
+}
+
+// fieldName returns the unique field name of the item named original.
+func (st *structType) fieldName(original string) string {
+	name := goName(original)
+	base := name
+	for i := 2; st.used[name]; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	st.used[name] = true
+	st.names[strings.ToUpper(original)] = name
+	return name
+}
+
+func (g *generator) copybook(cb *fixedlength.Copybook, record string, typeName string, source string) error {
+	rec, err := copybookRecord(cb, record)
+	if err != nil {
+		return err
+	}
+
+	name := typeName
+	if name == "" {
+		name = "Record"
+		if !rec.IsFiller() {
+			name = goName(rec.Name)
+		}
+	}
+	root := g.newType(name, "", func(name string) string {
+		if rec.IsFiller() {
+			return fmt.Sprintf("%s is the record of %s.", name, source)
+		}
+		return fmt.Sprintf("%s is the record %s of %s.", name, rec.Name, source)
+	})
+
+	marker := `"prefix=0"`
+	if !hasDependingOn(rec.Children) {
+		marker = fmt.Sprintf(`"length=%d,prefix=0"`, rec.Size())
+	}
+	root.fields = append(root.fields, goField{name: "_", typ: "struct{}", tags: []string{"record:" + marker}})
+
+	return g.items(root, rec.Children, false, false)
+}
+
+func copybookRecord(cb *fixedlength.Copybook, name string) (*fixedlength.CopybookItem, error) {
+	if name == "" {
+		if len(cb.Records) != 1 {
+			return nil, fmt.Errorf("%d records in the copybook, pick one with -record", len(cb.Records))
+		}
+		return cb.Records[0], nil
+	}
+	for _, rec := range cb.Records {
+		if strings.EqualFold(rec.Name, name) {
+			return rec, nil
+		}
+	}
+	return nil, fmt.Errorf("no record %s in the copybook", name)
+}
+
+func hasDependingOn(items []*fixedlength.CopybookItem) bool {
+	for _, it := range items {
+		if it.DependingOn != "" || hasDependingOn(it.Children) {
+			return true
+		}
+	}
+	return false
+}
+
+// items adds the fields of items to st. Relative layouts, in the element types of
+// arrays, use len tags, so every element starts after the previous one. Optional
+// fields, in the elements of OCCURS DEPENDING ON tables and the members of REDEFINES,
+// are left zero when records end before them or hold another member.
+func (g *generator) items(st *structType, items []*fixedlength.CopybookItem, relative bool, optional bool) error {
+	for _, it := range items {
+		if it.Renames != "" {
+			continue
+		}
+		// a record holds one member of a redefinition, the others may not decode
+		itemOptional := optional || it.DependingOn != "" || it.Redefines != "" || isRedefined(items, it)
+
+		if it.IsFiller() && !isRedefined(items, it) {
+			// the named items of FILLER groups belong to the enclosing group
+			if it.IsGroup() && it.Occurs == 0 {
+				if err := g.items(st, it.Children, relative, itemOptional); err != nil {
+					return err
+				}
+				continue
+			}
+			st.fields = append(st.fields, goField{
+				name: "_",
+				typ:  "struct{}",
+				tags: []string{layoutTag(it, it.Size(), relative), flagsTag("filler", itemOptional)},
+			})
+			continue
+		}
+
+		name := st.fieldName(it.Name)
+		f := goField{name: name}
+		if it.IsGroup() {
+			elem := g.newType(goName(it.Name), st.name, func(typeName string) string {
+				return fmt.Sprintf("%s is the group %s.", typeName, it.Name)
+			})
+			if err := g.items(elem, it.Children, relative || it.Occurs > 0, itemOptional); err != nil {
+				return err
+			}
+			f.typ = elem.name
+			f.doc = []string{fmt.Sprintf("%s is %s.", name, it.Name)}
+		} else {
+			typ, tags := elementaryType(it)
+			f.typ = typ
+			if it.Occurs > 0 {
+				f.tags = append(f.tags, fmt.Sprintf(`len:"%d"`, it.Length))
+			} else {
+				f.tags = append(f.tags, layoutTag(it, it.Length, relative))
+			}
+			if itemOptional {
+				f.tags = append(f.tags, flagsTag("", true))
+			}
+			f.tags = append(f.tags, tags...)
+			f.doc = []string{fmt.Sprintf("%s is %s, PIC %s%s.", name, it.Name, it.Picture, usageClause(it.Usage))}
+			if it.Numeric && it.Decimals > 0 {
+				f.doc = append(f.doc, fmt.Sprintf("It holds the value times 1%s.", strings.Repeat("0", it.Decimals)))
+			}
+			if it.Numeric && it.Digits > 18 {
+				f.doc = append(f.doc, fmt.Sprintf("Its %d digits may exceed the int64 range.", it.Digits))
+			}
+		}
+
+		if it.Occurs > 0 {
+			f.typ = fmt.Sprintf("[%d]%s", it.Occurs, f.typ)
+			f.doc = append(f.doc, occursDoc(it))
+		}
+		if it.Redefines != "" {
+			base, ok := st.names[strings.ToUpper(it.Redefines)]
+			if !ok {
+				return fmt.Errorf("line %d: %s redefines %s, which has no field", it.Line, it.Name, it.Redefines)
+			}
+			f.tags = append(f.tags, fmt.Sprintf("redefines:%q", base))
+			f.doc = append(f.doc, fmt.Sprintf("It redefines %s.", it.Redefines))
+		}
+		for _, c := range it.Conditions {
+			f.doc = append(f.doc, conditionDoc(c))
+		}
+		st.fields = append(st.fields, f)
+	}
+	return nil
+}
+
+// isRedefined reports whether one of items redefines it.
+func isRedefined(items []*fixedlength.CopybookItem, it *fixedlength.CopybookItem) bool {
+	for _, other := range items {
+		if other != it && strings.EqualFold(other.Redefines, it.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// flagsTag returns the flags tag of flag, with the optional flag when optional is set.
+func flagsTag(flag string, optional bool) string {
+	flags := []string{}
+	if flag != "" {
+		flags = append(flags, flag)
+	}
+	if optional {
+		flags = append(flags, "optional")
+	}
+	return fmt.Sprintf("flags:%q", strings.Join(flags, ","))
+}
+
+func layoutTag(it *fixedlength.CopybookItem, length int, relative bool) string {
+	if relative {
+		return fmt.Sprintf(`len:"%d"`, length)
+	}
+	return fmt.Sprintf(`range:"%d,%d"`, it.Offset, it.Offset+length)
+}
+
+// elementaryType returns the Go type and the conversion tags of an elementary item.
+func elementaryType(it *fixedlength.CopybookItem) (string, []string) {
+	if !it.Numeric {
+		return "string", nil
+	}
+
+	// implied decimal points scale the int64, so amounts aren't rounded through a float
+	tags := []string{`align:"right"`}
+	switch it.Usage {
+	case fixedlength.UsageBinary:
+		tags = append(tags, `usage:"comp"`)
+	case fixedlength.UsagePacked:
+		tags = append(tags, `usage:"comp-3"`)
+	}
+	return "int64", append(tags, signTags(it.Sign)...)
+}
+
+func signTags(s fixedlength.Sign) []string {
+	switch s {
+	case fixedlength.SignLeading:
+		return []string{`sign:"leading"`}
+	case fixedlength.SignTrailing:
+		return []string{`sign:"trailing"`}
+	case fixedlength.SignNone:
+		return []string{`sign:"none"`}
+	}
+	return nil
+}
+
+func usageClause(u fixedlength.Usage) string {
+	switch u {
+	case fixedlength.UsageBinary:
+		return " COMP"
+	case fixedlength.UsagePacked:
+		return " COMP-3"
+	}
+	return ""
+}
+
+func occursDoc(it *fixedlength.CopybookItem) string {
+	if it.DependingOn == "" {
+		return fmt.Sprintf("It occurs %d times.", it.Occurs)
+	}
+	return fmt.Sprintf("It occurs %d to %d times depending on %s, occurrences missing from shorter records decode as zero.",
+		it.OccursMin, it.Occurs, it.DependingOn)
+}
+
+func conditionDoc(c fixedlength.CopybookCondition) string {
+	values := make([]string, len(c.Values))
+	for i, v := range c.Values {
+		values[i] = strconv.Quote(v)
+	}
+	return fmt.Sprintf("%s: %s.", c.Name, strings.Join(values, ", "))
+}
+
+func (g *generator) schema(s *fixedlength.Schema, typeName string, source string) {
+	name := typeName
+	if name == "" {
+		name = "Record"
+	}
+	st := g.newType(name, "", func(name string) string {
+		return fmt.Sprintf("%s is the record of %s.", name, source)
+	})
+
+	marker := `"prefix=0"`
+	if s.Length > 0 {
+		marker = fmt.Sprintf(`"length=%d,prefix=0"`, s.Length)
+	}
+	st.fields = append(st.fields, goField{name: "_", typ: "struct{}", tags: []string{"record:" + marker}})
+	if s.RecordType != "" {
+		st.fields = append(st.fields, goField{
+			name: "_",
+			typ:  "struct{}",
+			tags: []string{fmt.Sprintf(`range:"0,%d"`, len([]rune(s.RecordType))), fmt.Sprintf("const:%q", s.RecordType)},
+			doc:  []string{"The record type."},
+		})
+	}
+
+	for _, sf := range s.Fields {
+		name := st.fieldName(sf.Name)
+		f := goField{
+			name: name,
+			tags: []string{fmt.Sprintf(`range:"%d,%d"`, sf.Offset, sf.Offset+sf.Length)},
+			doc:  []string{fmt.Sprintf("%s is %s.", name, sf.Name)},
+		}
+
+		switch sf.Type {
+		case fixedlength.FieldTypeInt:
+			f.typ = "int64"
+		case fixedlength.FieldTypeFloat:
+			f.typ = "float64"
+		case fixedlength.FieldTypeBool:
+			f.typ = "bool"
+		case fixedlength.FieldTypeTime:
+			f.typ = "time.Time"
+			layout := sf.Format
+			if layout == "" {
+				layout = fixedlength.DefaultTimeFormat
+			}
+			f.tags = append(f.tags, fmt.Sprintf("format:%q", layout))
+			g.usesTime = true
+		default:
+			f.typ = "string"
+		}
+
+		switch sf.Alignment {
+		case fixedlength.AlignmentTypeLeft:
+			f.tags = append(f.tags, `align:"left"`)
+		case fixedlength.AlignmentTypeRight:
+			f.tags = append(f.tags, `align:"right"`)
+		case fixedlength.AlignmentTypeCenter:
+			f.tags = append(f.tags, `align:"center"`)
+		}
+		if sf.Decimals > 0 {
+			f.tags = append(f.tags, fmt.Sprintf(`decimals:"%d"`, sf.Decimals))
+		}
+		switch sf.Usage {
+		case fixedlength.UsageBinary:
+			f.tags = append(f.tags, `usage:"comp"`)
+		case fixedlength.UsagePacked:
+			f.tags = append(f.tags, `usage:"comp-3"`)
+		}
+		f.tags = append(f.tags, signTags(sf.Sign)...)
+		if sf.Default != "" {
+			f.tags = append(f.tags, fmt.Sprintf("default:%q", sf.Default))
+		}
+		if sf.Redefines != "" {
+			f.tags = append(f.tags, fmt.Sprintf("redefines:%q", st.names[strings.ToUpper(sf.Redefines)]))
+			f.doc = append(f.doc, fmt.Sprintf("It redefines %s.", sf.Redefines))
+		}
+
+		if sf.Required {
+			f.doc = append(f.doc, "It is required.")
+		}
+		if len(sf.Values) > 0 {
+			f.doc = append(f.doc, fmt.Sprintf("Values: %s.", strings.Join(sf.Values, ", ")))
+		}
+		if sf.Pattern != "" {
+			f.doc = append(f.doc, fmt.Sprintf("Pattern: %s", sf.Pattern))
+		}
+		st.fields = append(st.fields, f)
+	}
+}
+
+func (g *generator) render(source string, pkg string) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by fixedlength-gen from %s. DO NOT EDIT.\n\npackage %s\n\n", source, pkg)
+	if g.usesTime {
+		b.WriteString("import \"time\"\n\n")
+	}
+
+	for _, st := range g.types {
+		fmt.Fprintf(&b, "// %s\ntype %s struct {\n", st.doc, st.name)
+		for _, f := range st.fields {
+			for _, line := range f.doc {
+				fmt.Fprintf(&b, "\t// %s\n", line)
+			}
+			fmt.Fprintf(&b, "\t%s %s", f.name, f.typ)
+			if len(f.tags) > 0 {
+				fmt.Fprintf(&b, " `%s`", strings.Join(f.tags, " "))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("}\n\n")
+	}
+
+	return format.Source(b.Bytes())
+}
+
+// initialisms are written in upper case in Go names, like ID in CustID.
+var initialisms = map[string]bool{"ID": true, "URL": true, "UUID": true, "API": true, "HTTP": true, "IP": true}
+
+// goName returns the exported Go name of an original field name, like CustID
+// for CUST-ID or OrderLines1Qty for ORDER-LINES(1).QTY.
+func goName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+	for _, p := range parts {
+		upper := strings.ToUpper(p)
+		if initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		runes := []rune(strings.ToLower(p))
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+
+	res := b.String()
+	switch {
+	case res == "":
+		return "Field"
+	case unicode.IsDigit([]rune(res)[0]):
+		return "N" + res
+	}
+	return res
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testCopybook = `
+       01  CUSTOMER-RECORD.
+           05  CUST-ID             PIC 9(6).
+           05  CUST-STATUS         PIC X.
+               88  STATUS-ACTIVE   VALUE 'A'.
+           05  FILLER              PIC X(2).
+           05  CUST-BALANCE        PIC S9(5)V99 COMP-3.
+           05  CUST-SINCE.
+               10  SINCE-YEAR      PIC 9(4).
+               10  SINCE-MONTH     PIC 99.
+           05  CUST-SINCE-N REDEFINES CUST-SINCE PIC 9(6).
+           05  CUST-PHONES OCCURS 2 TIMES.
+               10  PHONE-NUMBER    PIC X(8).
+           05  CUST-CODES          PIC X(2) OCCURS 3.
+`
+
+// squash collapses the alignment gofmt adds to struct fields.
+func squash(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func TestGenerateCopybook(t *testing.T) {
+	code, err := generate("customer.cpy", []byte(testCopybook), options{pkg: "billing"})
+	require.NoError(t, err)
+
+	got := squash(string(code))
+	for _, want := range []string{
+		"// Code generated by fixedlength-gen from customer.cpy. DO NOT EDIT. package billing",
+		"// CustomerRecord is the record CUSTOMER-RECORD of customer.cpy. type CustomerRecord struct {",
+		"_ struct{} `record:\"length=41,prefix=0\"`",
+		"// CustID is CUST-ID, PIC 9(6). CustID int64 `range:\"0,6\" align:\"right\" sign:\"none\"`",
+		"// STATUS-ACTIVE: \"A\". CustStatus string `range:\"6,7\"`",
+		"_ struct{} `range:\"7,9\" flags:\"filler\"`",
+		"// CustBalance is CUST-BALANCE, PIC S9(5)V99 COMP-3. // It holds the value times 100. CustBalance int64 `range:\"9,13\" align:\"right\" usage:\"comp-3\"`",
+		"CustSince CustSince",
+		"// It redefines CUST-SINCE. CustSinceN int64 `range:\"13,19\" flags:\"optional\" align:\"right\" sign:\"none\" redefines:\"CustSince\"`",
+		"// It occurs 2 times. CustPhones [2]CustPhones",
+		"CustCodes [3]string `len:\"2\"`",
+		"type CustSince struct { // SinceYear is SINCE-YEAR, PIC 9(4). SinceYear int64 `range:\"13,17\" flags:\"optional\" align:\"right\" sign:\"none\"`",
+		"type CustPhones struct { // PhoneNumber is PHONE-NUMBER, PIC X(8). PhoneNumber string `len:\"8\"` }",
+	} {
+		require.Contains(t, got, want)
+	}
+
+	// the output is stable
+	again, err := generate("customer.cpy", []byte(testCopybook), options{pkg: "billing"})
+	require.NoError(t, err)
+	require.Equal(t, code, again)
+}
+
+func TestGenerateSchema(t *testing.T) {
+	src := `
+recordType: "6"
+length: 20
+fields:
+  - {name: account-id, length: 6}
+  - {name: amount, length: 5, type: float, decimals: 2, align: right}
+  - {name: booked, length: 8, type: time}
+`
+	code, err := generate("payment.yaml", []byte(src), options{pkg: "billing", typeName: "Payment"})
+	require.NoError(t, err)
+
+	got := squash(string(code))
+	for _, want := range []string{
+		`import "time"`,
+		"type Payment struct {",
+		"_ struct{} `record:\"length=20,prefix=0\"`",
+		"_ struct{} `range:\"0,1\" const:\"6\"`",
+		"// AccountID is account-id. AccountID string `range:\"1,7\"`",
+		"Amount float64 `range:\"7,12\" align:\"right\" decimals:\"2\"`",
+		"Booked time.Time `range:\"12,20\" format:\"20060102\"`",
+	} {
+		require.Contains(t, got, want)
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"CUST-ID":            "CustID",
+		"ORDER-LINES(1).QTY": "OrderLines1Qty",
+		"amount_due":         "AmountDue",
+		"1ST-LINE":           "N1stLine",
+		"--":                 "Field",
+	}
+	for in, want := range tests {
+		require.Equal(t, want, goName(in), in)
+	}
+}
+
+func TestGenerateDuplicateNames(t *testing.T) {
+	src := `
+       01  REC.
+           05  HEADER.
+               10  CODE  PIC X.
+           05  TRAILER.
+               10  HEADER.
+                   15  CODE  PIC X.
+               10  CODE  PIC X.
+               10  CODE  PIC X.
+`
+	code, err := generate("rec.cpy", []byte(src), options{pkg: "p"})
+	require.NoError(t, err)
+	got := squash(string(code))
+	require.Contains(t, got, "Header TrailerHeader")
+	require.Contains(t, got, "type TrailerHeader struct")
+	require.Contains(t, got, "Code2 string `range:\"3,4\"`")
+}
+
+func TestGenerateDecimals(t *testing.T) {
+	src := `
+       01  LEDGER.
+           05  RATE     PIC V999.
+           05  TOTAL    PIC S9(17)V99 COMP-3.
+`
+	code, err := generate("ledger.cpy", []byte(src), options{pkg: "p"})
+	require.NoError(t, err)
+	got := squash(string(code))
+	require.Contains(t, got, "// It holds the value times 1000. Rate int64 `range:\"0,3\" align:\"right\" sign:\"none\"`")
+	require.Contains(t, got, "// It holds the value times 100. // Its 19 digits may exceed the int64 range. Total int64 `range:\"3,13\" align:\"right\" usage:\"comp-3\"`")
+}
+
+func TestGenerateOccursDependingOn(t *testing.T) {
+	src := `
+       01  ORDER.
+           05  ID          PIC X(10).
+           05  ITEM-COUNT  PIC 9(2).
+           05  ITEMS OCCURS 1 TO 3 DEPENDING ON ITEM-COUNT.
+               10  QTY     PIC 9(2).
+               10  FILLER  PIC X.
+`
+	code, err := generate("order.cpy", []byte(src), options{pkg: "p"})
+	require.NoError(t, err)
+	got := squash(string(code))
+	require.Contains(t, got, "_ struct{} `record:\"prefix=0\"`")
+	require.Contains(t, got, "// It occurs 1 to 3 times depending on ITEM-COUNT, occurrences missing from shorter records decode as zero. Items [3]Items")
+	require.Contains(t, got, "Qty int64 `len:\"2\" flags:\"optional\" align:\"right\" sign:\"none\"`")
+	require.Contains(t, got, "_ struct{} `len:\"1\" flags:\"filler,optional\"`")
+
+	src = `
+       01  ORDER.
+           05  CODE-COUNT  PIC 9.
+           05  CODES       PIC X OCCURS 1 TO 3 DEPENDING ON CODE-COUNT.
+`
+	code, err = generate("order.cpy", []byte(src), options{pkg: "p"})
+	require.NoError(t, err)
+	require.Contains(t, squash(string(code)), "Codes [3]string `len:\"1\" flags:\"optional\"`")
+}
+
+// TestGolden checks the structs of internal/golden, which round trips records
+// through them, are what the generator writes today.
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		in, typeName, out string
+	}{
+		{"customer.cpy", "Customer", "customer_gen.go"},
+		{"order.cpy", "", "order_gen.go"},
+		{"payment.yaml", "Payment", "payment_gen.go"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			src, err := os.ReadFile(filepath.Join("internal", "golden", tt.in))
+			require.NoError(t, err)
+			want, err := os.ReadFile(filepath.Join("internal", "golden", tt.out))
+			require.NoError(t, err)
+
+			code, err := generate(tt.in, src, options{pkg: "golden", typeName: tt.typeName})
+			require.NoError(t, err)
+			require.Equal(t, string(want), string(code), "run go generate ./cmd/fixedlength-gen/internal/golden")
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "customer.cpy")
+	require.NoError(t, os.WriteFile(in, []byte(testCopybook), 0o644))
+
+	var stdout bytes.Buffer
+	require.NoError(t, run([]string{"-in", in, "-type", "Customer", "-package", "billing"}, &stdout))
+	require.Contains(t, stdout.String(), "type Customer struct")
+
+	out := filepath.Join(dir, "customer_gen.go")
+	require.NoError(t, run([]string{"-in", in, "-out", out, "-package", "billing"}, &stdout))
+	code, err := os.ReadFile(out)
+	require.NoError(t, err)
+	require.Contains(t, string(code), "type CustomerRecord struct")
+
+	require.ErrorContains(t, run(nil, &stdout), "missing -in")
+	require.ErrorContains(t, run([]string{"-in", in, "-record", "OTHER"}, &stdout), "no record OTHER")
+}
@@ -0,0 +1,117 @@
+package fixedlength
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Codec marshals and unmarshals records using its own Config
+// instead of the global one returned by GetConfig.
+type Codec struct {
+	config Config
+
+	mu         sync.RWMutex
+	converters map[reflect.Type]converter
+}
+
+// NewCodec creates a codec with the given configuration.
+// Start from DefaultConfig to keep the defaults for the settings you don't change.
+func NewCodec(config Config) *Codec {
+	return &Codec{config: config}
+}
+
+// Config returns a copy of the codec configuration.
+func (c *Codec) Config() Config {
+	return c.config
+}
+
+// width measures s in the codec position unit.
+func (c *Codec) width(s string) int {
+	switch c.config.PositionUnit {
+	case PositionUnitByte:
+		return len(s)
+	case PositionUnitColumn:
+		return DisplayWidth(s)
+	}
+	return utf8.RuneCountInString(s)
+}
+
+// fillerUnit returns filler as one position unit. In byte mode it's the raw byte.
+func (c *Codec) fillerUnit(filler byte) string {
+	if c.config.PositionUnit == PositionUnitByte {
+		return string([]byte{filler})
+	}
+	return string(rune(filler))
+}
+
+// trimFiller trims filler from the trim sides of the decoded text of a field. In byte
+// mode the filler is a byte of the record charset, trimmed in its decoded form: a raw
+// byte when the field has no charset.
+func (c *Codec) trimFiller(value string, trim TrimType, t tag, filler byte) string {
+	if c.config.PositionUnit != PositionUnitByte || filler < utf8.RuneSelf && c.fieldCharset(t) == nil {
+		return TrimString(value, trim, trimCutset(filler))
+	}
+
+	unit, err := decodeText(c.fieldCharset(t), []byte{filler})
+	if err != nil || unit == "" {
+		return value
+	}
+	if unit == " " {
+		return TrimString(value, trim, "")
+	}
+
+	if trim == TrimTypeLeft || trim == TrimTypeBoth || trim == TrimTypeDefault {
+		for strings.HasPrefix(value, unit) {
+			value = value[len(unit):]
+		}
+	}
+	if trim == TrimTypeRight || trim == TrimTypeBoth || trim == TrimTypeDefault {
+		for strings.HasSuffix(value, unit) {
+			value = value[:len(value)-len(unit)]
+		}
+	}
+	return value
+}
+
+// format pads str to the field length in position units with filler.
+func (c *Codec) format(str string, t tag, alignmentType AlignmentType, filler byte) (string, error) {
+	if c.config.PositionUnit == PositionUnitByte {
+		// in byte mode the width is only known once the text is in the record charset
+		str, err := c.encodeText(c.fieldCharset(t), str)
+		if err != nil {
+			return "", err
+		}
+		return formatWithWidth(str, len(str), t.Len(), alignmentType, c.fillerUnit(filler))
+	}
+	// skipped characters must be gone before padding, or they'd shift the next fields
+	str = c.dropUnmappable(str)
+	if c.config.PositionUnit == PositionUnitColumn {
+		return formatWithWidth(str, DisplayWidth(str), t.Len(), alignmentType, c.fillerUnit(filler))
+	}
+	return formatWithFiller(str, t.Len(), alignmentType, filler)
+}
+
+// checkFieldTag reports tag settings the codec can't honor.
+func (c *Codec) checkFieldTag(t tag) error {
+	if t.charset != nil && c.config.PositionUnit != PositionUnitByte {
+		return fmt.Errorf("charset tag requires byte positions")
+	}
+	if t.usage != UsageDisplay && c.config.PositionUnit != PositionUnitByte {
+		return fmt.Errorf("binary usage requires byte positions")
+	}
+	if t.sign.separate() && t.usage != UsageDisplay {
+		return fmt.Errorf("separate signs require display usage")
+	}
+	if t.usage == UsageBinary && (t.Len() < 1 || t.Len() > 8) {
+		return fmt.Errorf("binary usage takes 1 to 8 bytes, not %d", t.Len())
+	}
+	return nil
+}
+
+// defaultCodec is used by the package level functions, it follows the global config.
+func defaultCodec() *Codec {
+	return &Codec{config: *GetConfig()}
+}
@@ -0,0 +1,147 @@
+package fixedlength
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodecFiller(t *testing.T) {
+	type rec struct {
+		_        struct{} `record:"length=16,prefix=0"`
+		Name     string   `range:"0,6"`
+		Amount   int      `range:"6,12" fill:"*"`
+		Reserved string   `range:"12,14" fill:"#"`
+	}
+
+	cfg := DefaultConfig()
+	cfg.Filler = 0x00
+	c := NewCodec(cfg)
+
+	res, err := c.Marshal(&rec{Name: "abc", Amount: 1234})
+	require.NoError(t, err)
+	require.Equal(t, "abc\x00\x00\x00**1234##\x00\x00", string(res))
+
+	var v rec
+	require.NoError(t, c.Unmarshal(res, &v))
+	require.Equal(t, rec{Name: "abc", Amount: 1234}, v)
+}
+
+func TestDefaultConfig(t *testing.T) {
+	cfg := NewCodec(DefaultConfig()).Config()
+	require.Equal(t, AlignmentTypeLeft, cfg.AlignmentType)
+	require.True(t, cfg.NumbersWithLeadingZeroes)
+	require.Equal(t, 2, cfg.TypePrefixLength)
+	require.Equal(t, byte(' '), cfg.Filler)
+}
+
+func TestCodecPositionUnit(t *testing.T) {
+	type rec struct {
+		Name string `range:"0,6"`
+		Code string `range:"6,8"`
+	}
+
+	cfg := DefaultConfig()
+	cfg.TypePrefixLength = 0
+
+	t.Run("rune", func(t *testing.T) {
+		c := NewCodec(cfg)
+		res, err := c.Marshal(&rec{Name: "Müll", Code: "AB"})
+		require.NoError(t, err)
+		require.Equal(t, "Müll  AB", string(res))
+
+		var v rec
+		require.NoError(t, c.Unmarshal(res, &v))
+		require.Equal(t, rec{Name: "Müll", Code: "AB"}, v)
+	})
+
+	t.Run("byte", func(t *testing.T) {
+		cfg := cfg
+		cfg.PositionUnit = PositionUnitByte
+		c := NewCodec(cfg)
+		res, err := c.Marshal(&rec{Name: "Müll", Code: "AB"})
+		require.NoError(t, err)
+		require.Equal(t, "Müll AB", string(res))
+		require.Len(t, res, 8)
+
+		var v rec
+		require.NoError(t, c.Unmarshal(res, &v))
+		require.Equal(t, rec{Name: "Müll", Code: "AB"}, v)
+
+		_, err = c.Marshal(&rec{Name: "Müller", Code: "AB"})
+		require.Error(t, err)
+	})
+}
+
+func TestCodecByteFiller(t *testing.T) {
+	type rec struct {
+		Name string   `range:"0,6" fill:"0xFF"`
+		_    struct{} `range:"6,8" flags:"filler" fill:"0xFF"`
+	}
+
+	cfg := DefaultConfig()
+	cfg.TypePrefixLength = 0
+	cfg.PositionUnit = PositionUnitByte
+	cfg.Strict = true
+	c := NewCodec(cfg)
+
+	res, err := c.Marshal(&rec{Name: "ab"})
+	require.NoError(t, err)
+	require.Equal(t, "ab\xff\xff\xff\xff\xff\xff", string(res))
+
+	var v rec
+	require.NoError(t, c.Unmarshal(res, &v))
+	require.Equal(t, rec{Name: "ab"}, v)
+
+	// only the filler byte is trimmed, not other bytes that aren't UTF-8
+	require.NoError(t, c.Unmarshal([]byte("ab\xfe\xff\xff\xff\xff\xff"), &v))
+	require.Equal(t, rec{Name: "ab\xfe"}, v)
+}
+
+func TestCodecColumnPositions(t *testing.T) {
+	type rec struct {
+		Name  string `range:"0,8"`
+		City  string `range:"8,14"`
+		Count int    `range:"14,17"`
+	}
+
+	cfg := DefaultConfig()
+	cfg.TypePrefixLength = 0
+	cfg.PositionUnit = PositionUnitColumn
+	c := NewCodec(cfg)
+
+	res, err := c.Marshal(&rec{Name: "山田太郎", City: "José", Count: 5})
+	require.NoError(t, err)
+	require.Equal(t, "山田太郎José  005", string(res))
+	require.Equal(t, 17, DisplayWidth(string(res)))
+
+	var v rec
+	require.NoError(t, c.Unmarshal(res, &v))
+	require.Equal(t, rec{Name: "山田太郎", City: "José", Count: 5}, v)
+
+	_, err = c.Marshal(&rec{Name: "山田太郎様", City: "x"})
+	require.Error(t, err)
+}
+
+func TestCodecColumnSplitCharacter(t *testing.T) {
+	type rec struct {
+		Name string `range:"0,3"`
+		City string `range:"3,6"`
+	}
+
+	cfg := DefaultConfig()
+	cfg.TypePrefixLength = 0
+	cfg.PositionUnit = PositionUnitColumn
+	c := NewCodec(cfg)
+
+	var v rec
+	err := c.Unmarshal([]byte("山田太x"), &v)
+	require.ErrorIs(t, err, ErrSplitCharacter)
+
+	// wide characters are padded to the field width in columns, not runes
+	res, err := c.Marshal(&rec{Name: "山", City: "x"})
+	require.NoError(t, err)
+	require.Equal(t, "山 x  ", string(res))
+	require.NoError(t, c.Unmarshal(res, &v))
+	require.Equal(t, rec{Name: "山", City: "x"}, v)
+}
@@ -4,20 +4,59 @@ import (
 	"sync"
 )
 
+// PositionUnit selects how ranges and widths are counted.
+type PositionUnit int
+
+var (
+	PositionUnitRune PositionUnit = 0 // positions count UTF-8 characters
+	PositionUnitByte PositionUnit = 1 // positions count bytes, as in most spec documents
+	// PositionUnitColumn counts display columns: full width characters take 2, combining marks 0.
+	PositionUnitColumn PositionUnit = 2
+)
+
 type Config struct {
 	AlignmentType            AlignmentType
 	NumbersWithLeadingZeroes bool
+	// TypePrefixLength is the number of leading characters reserved for the record type.
+	// They are filled outside of Marshal, so the output starts right after them.
+	TypePrefixLength int
+	// Filler pads string fields and gaps between fields, and is trimmed on decode.
+	Filler byte
+	// PositionUnit is the unit of ranges and field widths, runes by default.
+	PositionUnit PositionUnit
+	// Charset of the record data, nil means UTF-8.
+	Charset Charset
+	// Unmappable decides what happens to characters Charset can't encode.
+	Unmappable UnmappableType
+	// Replacement is written for unmappable characters with UnmappableTypeReplace.
+	Replacement byte
+	// Transform prepares string fields before they are padded on encode.
+	Transform TextTransform
+	// Overflow is the default policy for strings longer than their field.
+	Overflow OverflowType
+	// OnTruncate, when set, reports every truncated field.
+	OnTruncate TruncateFunc
+	// Strict makes Unmarshal reject FILLER ranges that aren't blank.
+	Strict bool
 }
 
 var once sync.Once
 var instance Config
 
+// DefaultConfig returns the configuration used when nothing is customized.
+func DefaultConfig() Config {
+	return Config{
+		AlignmentType:            AlignmentTypeLeft,
+		NumbersWithLeadingZeroes: true,
+		TypePrefixLength:         2,
+		Filler:                   ' ',
+		Replacement:              '?',
+	}
+}
+
 func GetConfig() *Config {
 	once.Do(func() {
-		instance = Config{
-			AlignmentType:            AlignmentTypeLeft,
-			NumbersWithLeadingZeroes: true,
-		}
+		instance = DefaultConfig()
 	})
 
 	return &instance
@@ -0,0 +1,68 @@
+package fixedlength
+
+import (
+	"reflect"
+	"sync"
+)
+
+// converter encodes and decodes values of one type, registered with RegisterConverter.
+type converter struct {
+	encode func(v reflect.Value, field FieldInfo) (string, error)
+	decode func(s string, field FieldInfo) (reflect.Value, error)
+}
+
+var (
+	convertersMu sync.RWMutex
+	converters   = map[reflect.Type]converter{}
+)
+
+// RegisterConverter registers encode and decode functions for values of type T,
+// for types you can't add methods to (time.Duration, netip.Addr, url.URL...).
+// Converters are consulted before custom marshalers and kind based handling.
+// The encoded string is padded like a Marshaler result, decode gets the trimmed field.
+func RegisterConverter[T any](encode func(v T, field FieldInfo) (string, error), decode func(s string, field FieldInfo) (T, error)) {
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	converters[reflect.TypeOf((*T)(nil)).Elem()] = newConverter(encode, decode)
+}
+
+// RegisterCodecConverter registers a converter for type T used by c only.
+// It takes precedence over the converters registered with RegisterConverter.
+func RegisterCodecConverter[T any](c *Codec, encode func(v T, field FieldInfo) (string, error), decode func(s string, field FieldInfo) (T, error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.converters == nil {
+		c.converters = map[reflect.Type]converter{}
+	}
+	c.converters[reflect.TypeOf((*T)(nil)).Elem()] = newConverter(encode, decode)
+}
+
+func newConverter[T any](encode func(v T, field FieldInfo) (string, error), decode func(s string, field FieldInfo) (T, error)) converter {
+	return converter{
+		encode: func(v reflect.Value, field FieldInfo) (string, error) {
+			return encode(v.Interface().(T), field)
+		},
+		decode: func(s string, field FieldInfo) (reflect.Value, error) {
+			v, err := decode(s, field)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			return reflect.ValueOf(&v).Elem(), nil
+		},
+	}
+}
+
+// converterFor returns the converter of type t, the codec ones first.
+func (c *Codec) converterFor(t reflect.Type) (converter, bool) {
+	c.mu.RLock()
+	conv, ok := c.converters[t]
+	c.mu.RUnlock()
+	if ok {
+		return conv, true
+	}
+
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+	conv, ok = converters[t]
+	return conv, ok
+}
@@ -0,0 +1,65 @@
+package fixedlength
+
+import (
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterConverter(t *testing.T) {
+	// the global registry outlives the test
+	t.Cleanup(func() {
+		convertersMu.Lock()
+		defer convertersMu.Unlock()
+		delete(converters, reflect.TypeOf(time.Duration(0)))
+	})
+	RegisterConverter(
+		func(d time.Duration, f FieldInfo) (string, error) {
+			return strconv.FormatInt(int64(d/time.Second), 10), nil
+		},
+		func(s string, f FieldInfo) (time.Duration, error) {
+			n, err := strconv.ParseInt(s, 10, 64)
+			return time.Duration(n) * time.Second, err
+		},
+	)
+
+	type rec struct {
+		Timeout time.Duration `range:"2,8" align:"right"`
+		Link    url.URL       `range:"8,30"`
+	}
+
+	cfg := DefaultConfig()
+	c := NewCodec(cfg)
+	RegisterCodecConverter(c,
+		func(u url.URL, f FieldInfo) (string, error) {
+			return u.String(), nil
+		},
+		func(s string, f FieldInfo) (url.URL, error) {
+			u, err := url.Parse(s)
+			if err != nil {
+				return url.URL{}, err
+			}
+			return *u, nil
+		},
+	)
+
+	v := rec{Timeout: 90 * time.Second, Link: url.URL{Scheme: "https", Host: "example.com", Path: "/a"}}
+	res, err := c.Marshal(&v)
+	require.NoError(t, err)
+	require.Equal(t, "    90https://example.com/a ", string(res))
+
+	var got rec
+	require.NoError(t, c.Unmarshal(append([]byte("01"), res...), &got))
+	require.Equal(t, v, got)
+
+	// the url converter is only known to the codec
+	_, ok := defaultCodec().converterFor(reflect.TypeOf(url.URL{}))
+	require.False(t, ok)
+
+	_, ok = defaultCodec().converterFor(reflect.TypeOf(time.Duration(0)))
+	require.True(t, ok)
+}
@@ -0,0 +1,871 @@
+package fixedlength
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var ErrInvalidCopybook = errors.New("fixedlength: invalid copybook")
+
+// Copybook is a parsed COBOL copybook: the data description entries of one or
+// more records, with their offsets computed.
+type Copybook struct {
+	Records []*CopybookItem
+}
+
+// CopybookItem is a data description entry. Groups have children, elementary
+// items a picture. Offsets are 0-based from the start of the record, tables
+// (OCCURS) have the offset and length of their first occurrence.
+type CopybookItem struct {
+	Level int
+	Name  string // FILLER for unnamed entries
+	Line  int
+
+	Picture  string
+	Usage    Usage
+	Signed   bool
+	Sign     Sign // SignNone for unsigned numbers
+	Numeric  bool // only 9, S and V in the picture
+	Digits   int
+	Decimals int // digits after the V
+
+	Occurs      int // maximum occurrences, 0 if the item is not a table
+	OccursMin   int
+	DependingOn string
+	Redefines   string
+	// Renames and RenamesThru are the items a level 66 entry spans.
+	Renames     string
+	RenamesThru string
+
+	Conditions []CopybookCondition // level 88 entries
+
+	Offset   int
+	Length   int // of one occurrence, in bytes
+	Children []*CopybookItem
+}
+
+// CopybookCondition is a level 88 condition name and the values it stands for.
+type CopybookCondition struct {
+	Name   string
+	Values []string
+}
+
+// IsGroup reports whether the item has subordinate items.
+func (it *CopybookItem) IsGroup() bool {
+	return it.Picture == "" && it.Renames == ""
+}
+
+// IsFiller reports whether the item has no name.
+func (it *CopybookItem) IsFiller() bool {
+	return strings.EqualFold(it.Name, "FILLER")
+}
+
+// Size returns the bytes taken by all the occurrences of the item.
+func (it *CopybookItem) Size() int {
+	if it.Occurs > 0 {
+		return it.Length * it.Occurs
+	}
+	return it.Length
+}
+
+// LoadCopybook parses a copybook describing one record into a Schema.
+func LoadCopybook(r io.Reader) (*Schema, error) {
+	cb, err := ParseCopybook(r)
+	if err != nil {
+		return nil, err
+	}
+	return cb.Schema("")
+}
+
+// ParseCopybook parses the data description entries of a COBOL copybook, in fixed
+// (sequence area, indicator column, 72 columns) or free format.
+// Levels 01 to 49, 66 (RENAMES), 77 and 88 (conditions) are supported, with the
+// PICTURE, USAGE, OCCURS [DEPENDING ON], REDEFINES and VALUE clauses.
+// Other clauses (SYNC, JUSTIFIED, BLANK WHEN ZERO...) are ignored.
+func ParseCopybook(r io.Reader) (*Copybook, error) {
+	tokens, err := copybookTokens(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &copybookParser{tokens: tokens}
+	cb := &Copybook{}
+	var stack []*CopybookItem
+	var last *CopybookItem
+	for !p.done() {
+		it, err := p.entry()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case it.Level == 88:
+			if last == nil {
+				return nil, copybookError(it.Line, "condition %s has no item", it.Name)
+			}
+			last.Conditions = append(last.Conditions, CopybookCondition{Name: it.Name, Values: it.values})
+			continue
+
+		case it.Level == 66:
+			if len(cb.Records) == 0 {
+				return nil, copybookError(it.Line, "RENAMES %s has no record", it.Name)
+			}
+			rec := cb.Records[len(cb.Records)-1]
+			rec.Children = append(rec.Children, &it.CopybookItem)
+			last = &it.CopybookItem
+			continue
+
+		case it.Level == 1 || it.Level == 77:
+			cb.Records = append(cb.Records, &it.CopybookItem)
+			stack = []*CopybookItem{&it.CopybookItem}
+			last = &it.CopybookItem
+			continue
+
+		case it.Level < 1 || it.Level > 49:
+			return nil, copybookError(it.Line, "invalid level %02d", it.Level)
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].Level >= it.Level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			if len(cb.Records) > 0 {
+				return nil, copybookError(it.Line, "level %02d of %s is not below its record", it.Level, it.Name)
+			}
+			// copybooks often start below the record level
+			root := &CopybookItem{Level: 1, Name: "FILLER", Line: it.Line}
+			cb.Records = append(cb.Records, root)
+			stack = []*CopybookItem{root}
+		}
+
+		parent := stack[len(stack)-1]
+		if !parent.IsGroup() {
+			return nil, copybookError(it.Line, "%s is below the elementary item %s", it.Name, parent.Name)
+		}
+		parent.Children = append(parent.Children, &it.CopybookItem)
+		stack = append(stack, &it.CopybookItem)
+		last = &it.CopybookItem
+	}
+
+	if len(cb.Records) == 0 {
+		return nil, fmt.Errorf("%w: no data description entries", ErrInvalidCopybook)
+	}
+	for _, rec := range cb.Records {
+		if _, err := layoutCopybookItem(rec, 0); err != nil {
+			return nil, err
+		}
+		if err := layoutRenames(rec); err != nil {
+			return nil, err
+		}
+	}
+	return cb, nil
+}
+
+func copybookError(line int, format string, args ...any) error {
+	return fmt.Errorf("%w: line %d: %s", ErrInvalidCopybook, line, fmt.Sprintf(format, args...))
+}
+
+// layoutCopybookItem computes the offsets and lengths of it and its children from
+// offset, and reports whether it holds a variable table (OCCURS DEPENDING ON).
+func layoutCopybookItem(it *CopybookItem, offset int) (bool, error) {
+	it.Offset = offset
+	if it.Renames != "" {
+		return false, nil
+	}
+	if !it.IsGroup() {
+		return it.DependingOn != "", nil
+	}
+
+	variable := false
+	pos := offset
+	end := offset
+	for i, child := range it.Children {
+		if child.Renames != "" {
+			continue
+		}
+
+		start := pos
+		if child.Redefines != "" {
+			base := copybookSibling(it.Children[:i], child.Redefines)
+			if base == nil {
+				return false, copybookError(child.Line, "%s redefines %s, which is not a preceding item of the same level", child.Name, child.Redefines)
+			}
+			start = base.Offset
+		} else if variable {
+			return false, copybookError(child.Line, "%s follows a table with OCCURS DEPENDING ON", child.Name)
+		}
+
+		childVariable, err := layoutCopybookItem(child, start)
+		if err != nil {
+			return false, err
+		}
+		variable = variable || childVariable
+
+		if child.Redefines == "" {
+			pos = start + child.Size()
+		}
+		end = max(end, start+child.Size())
+	}
+	it.Length = end - offset
+
+	if it.DependingOn != "" && variable {
+		return false, copybookError(it.Line, "nested OCCURS DEPENDING ON in %s is not supported", it.Name)
+	}
+	return variable || it.DependingOn != "", nil
+}
+
+func containsDependingOn(items []*CopybookItem) bool {
+	for _, it := range items {
+		if it.DependingOn != "" || containsDependingOn(it.Children) {
+			return true
+		}
+	}
+	return false
+}
+
+func copybookSibling(items []*CopybookItem, name string) *CopybookItem {
+	for i := len(items) - 1; i >= 0; i-- {
+		if strings.EqualFold(items[i].Name, name) {
+			return items[i]
+		}
+	}
+	return nil
+}
+
+// findCopybookItem returns the first item named name below it.
+func findCopybookItem(it *CopybookItem, name string) *CopybookItem {
+	for _, child := range it.Children {
+		if strings.EqualFold(child.Name, name) && child.Renames == "" {
+			return child
+		}
+		if found := findCopybookItem(child, name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// layoutRenames places the level 66 entries of rec over the items they span.
+func layoutRenames(rec *CopybookItem) error {
+	for _, it := range rec.Children {
+		if it.Renames == "" {
+			continue
+		}
+
+		from := findCopybookItem(rec, it.Renames)
+		if from == nil {
+			return copybookError(it.Line, "%s renames unknown item %s", it.Name, it.Renames)
+		}
+		to := from
+		if it.RenamesThru != "" {
+			if to = findCopybookItem(rec, it.RenamesThru); to == nil {
+				return copybookError(it.Line, "%s renames unknown item %s", it.Name, it.RenamesThru)
+			}
+		}
+		if to.Offset+to.Size() <= from.Offset {
+			return copybookError(it.Line, "%s renames %s THRU %s in reverse order", it.Name, it.Renames, it.RenamesThru)
+		}
+		it.Offset = from.Offset
+		it.Length = to.Offset + to.Size() - from.Offset
+	}
+	return nil
+}
+
+// copybookToken is a word, literal or separator period of a copybook.
+type copybookToken struct {
+	text    string
+	line    int
+	literal bool
+}
+
+// copybookTokens splits the code areas of a copybook into tokens.
+func copybookTokens(r io.Reader) ([]copybookToken, error) {
+	var tokens []copybookToken
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		code, ok := copybookCode(scanner.Text())
+		if !ok {
+			continue
+		}
+
+		for i := 0; i < len(code); {
+			ch := code[i]
+			switch {
+			case ch == ' ' || ch == '\t' || ch == ';':
+				i++
+			case ch == ',' && (i+1 == len(code) || code[i+1] == ' '):
+				i++
+			case ch == '.' && (i+1 == len(code) || code[i+1] == ' ' || code[i+1] == '\t'):
+				tokens = append(tokens, copybookToken{text: ".", line: line})
+				i++
+			case ch == '\'' || ch == '"':
+				end := strings.IndexByte(code[i+1:], ch)
+				if end < 0 {
+					return nil, copybookError(line, "unterminated literal")
+				}
+				tokens = append(tokens, copybookToken{text: code[i+1 : i+1+end], line: line, literal: true})
+				i += end + 2
+			default:
+				start := i
+				for i < len(code) && code[i] != ' ' && code[i] != '\t' && code[i] != ';' {
+					if code[i] == '.' && (i+1 == len(code) || code[i+1] == ' ' || code[i+1] == '\t') {
+						break
+					}
+					if code[i] == ',' && (i+1 == len(code) || code[i+1] == ' ') {
+						break
+					}
+					i++
+				}
+				tokens = append(tokens, copybookToken{text: code[start:i], line: line})
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// copybookCode returns the code of a line without the sequence area, the indicator
+// column and the identification area of the fixed format. Comments have none. Lines
+// without a fixed format sequence area and indicator are free format, kept whole.
+func copybookCode(line string) (string, bool) {
+	if len(line) >= 7 && isSequenceArea(line[:6]) {
+		switch line[6] {
+		case '*', '/', 'D', 'd':
+			return "", false
+		case ' ', '-':
+			// code starts in area A or B, from column 8
+			line = line[7:]
+			if len(line) > 65 {
+				line = line[:65]
+			}
+		}
+	}
+
+	if i := strings.Index(line, "*>"); i >= 0 {
+		line = line[:i]
+	}
+	if strings.HasPrefix(strings.TrimSpace(line), "*") {
+		return "", false
+	}
+	return line, strings.TrimSpace(line) != ""
+}
+
+// isSequenceArea reports whether s, the first 6 columns of a line, is a blank or
+// numbered sequence area. Indented free format code, like "    05", is neither.
+func isSequenceArea(s string) bool {
+	return strings.Trim(s, " ") == "" || strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) }) < 0
+}
+
+// copybookEntry is a parsed data description entry, with its VALUE clause.
+type copybookEntry struct {
+	CopybookItem
+	values []string
+}
+
+type copybookParser struct {
+	tokens []copybookToken
+	pos    int
+}
+
+func (p *copybookParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+// peek returns the next token of the entry, "." at its end.
+func (p *copybookParser) peek() copybookToken {
+	if p.done() {
+		line := 0
+		if len(p.tokens) > 0 {
+			line = p.tokens[len(p.tokens)-1].line
+		}
+		return copybookToken{text: ".", line: line}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *copybookParser) next() copybookToken {
+	t := p.peek()
+	if !p.done() {
+		p.pos++
+	}
+	return t
+}
+
+// keyword consumes the next token if it is one of words.
+func (p *copybookParser) keyword(words ...string) bool {
+	t := p.peek()
+	if t.literal {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(t.text, w) {
+			p.pos++
+			return true
+		}
+	}
+	return false
+}
+
+// word consumes a name or number.
+func (p *copybookParser) word(what string) (copybookToken, error) {
+	t := p.next()
+	if t.text == "." && !t.literal {
+		return t, copybookError(t.line, "missing %s", what)
+	}
+	return t, nil
+}
+
+func (p *copybookParser) number(what string) (int, error) {
+	t, err := p.word(what)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(t.text)
+	if err != nil {
+		return 0, copybookError(t.line, "invalid %s %q", what, t.text)
+	}
+	return n, nil
+}
+
+var copybookUsages = map[string]Usage{
+	"DISPLAY":         UsageDisplay,
+	"COMP":            UsageBinary,
+	"COMP-4":          UsageBinary,
+	"COMP-5":          UsageBinary,
+	"BINARY":          UsageBinary,
+	"COMPUTATIONAL":   UsageBinary,
+	"COMPUTATIONAL-4": UsageBinary,
+	"COMPUTATIONAL-5": UsageBinary,
+	"COMP-3":          UsagePacked,
+	"COMPUTATIONAL-3": UsagePacked,
+	"PACKED-DECIMAL":  UsagePacked,
+}
+
+// copybookClauses starts the clauses of an entry, an entry without a name starts with one.
+var copybookClauses = []string{
+	"REDEFINES", "PIC", "PICTURE", "USAGE", "OCCURS", "VALUE", "VALUES", "RENAMES",
+	"SIGN", "SYNC", "SYNCHRONIZED", "JUST", "JUSTIFIED", "BLANK", "GLOBAL", "EXTERNAL",
+	"DISPLAY", "COMP", "COMP-1", "COMP-2", "COMP-3", "COMP-4", "COMP-5", "BINARY",
+	"COMPUTATIONAL", "COMPUTATIONAL-1", "COMPUTATIONAL-2", "COMPUTATIONAL-3",
+	"COMPUTATIONAL-4", "COMPUTATIONAL-5", "PACKED-DECIMAL", "INDEX", "POINTER",
+}
+
+func isCopybookClause(t copybookToken) bool {
+	if t.literal {
+		return false
+	}
+	for _, c := range copybookClauses {
+		if strings.EqualFold(t.text, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// entry parses a data description entry up to its separator period.
+func (p *copybookParser) entry() (*copybookEntry, error) {
+	levelTok := p.next()
+	level, err := strconv.Atoi(levelTok.text)
+	if err != nil || levelTok.literal {
+		return nil, copybookError(levelTok.line, "expected a level number, got %q", levelTok.text)
+	}
+
+	it := &copybookEntry{CopybookItem: CopybookItem{Level: level, Name: "FILLER", Line: levelTok.line}}
+	if t := p.peek(); t.text != "." && !isCopybookClause(t) {
+		it.Name = p.next().text
+	}
+
+	sign := SignOverpunch
+	for {
+		t := p.next()
+		if t.text == "." && !t.literal {
+			break
+		}
+
+		clause := strings.ToUpper(t.text)
+		switch {
+		case clause == "REDEFINES":
+			name, err := p.word("REDEFINES item")
+			if err != nil {
+				return nil, err
+			}
+			it.Redefines = name.text
+
+		case clause == "PIC" || clause == "PICTURE":
+			p.keyword("IS")
+			pic, err := p.word("picture")
+			if err != nil {
+				return nil, err
+			}
+			it.Picture = strings.ToUpper(pic.text)
+
+		case clause == "USAGE":
+			p.keyword("IS")
+			u, err := p.word("usage")
+			if err != nil {
+				return nil, err
+			}
+			if err := it.setUsage(u); err != nil {
+				return nil, err
+			}
+
+		case copybookUsages[clause] != UsageDisplay || clause == "DISPLAY" || isCopybookClause(t) && strings.HasPrefix(clause, "COMP"):
+			if err := it.setUsage(t); err != nil {
+				return nil, err
+			}
+
+		case clause == "INDEX" || clause == "POINTER":
+			return nil, copybookError(t.line, "USAGE %s of %s is not supported", clause, it.Name)
+
+		case clause == "OCCURS":
+			if err := p.occurs(it); err != nil {
+				return nil, err
+			}
+
+		case clause == "VALUE" || clause == "VALUES":
+			p.keyword("IS", "ARE")
+			for v := p.peek(); v.text != "." && !isCopybookClause(v) || v.literal; v = p.peek() {
+				if p.keyword("THRU", "THROUGH") {
+					continue
+				}
+				it.values = append(it.values, p.next().text)
+			}
+
+		case clause == "RENAMES":
+			from, err := p.word("RENAMES item")
+			if err != nil {
+				return nil, err
+			}
+			it.Renames = from.text
+			if p.keyword("THRU", "THROUGH") {
+				to, err := p.word("RENAMES THRU item")
+				if err != nil {
+					return nil, err
+				}
+				it.RenamesThru = to.text
+			}
+
+		case clause == "SIGN":
+			p.keyword("IS")
+			leading := p.keyword("LEADING")
+			if !leading {
+				p.keyword("TRAILING")
+			}
+			switch {
+			case p.keyword("SEPARATE"):
+				p.keyword("CHARACTER")
+				sign = SignTrailing
+				if leading {
+					sign = SignLeading
+				}
+			case leading:
+				return nil, copybookError(t.line, "SIGN LEADING of %s is only supported with SEPARATE", it.Name)
+			}
+
+		case clause == "SYNC" || clause == "SYNCHRONIZED":
+			p.keyword("LEFT", "RIGHT")
+
+		case clause == "JUST" || clause == "JUSTIFIED":
+			p.keyword("RIGHT")
+
+		case clause == "BLANK":
+			p.keyword("WHEN")
+			p.keyword("ZERO", "ZEROS", "ZEROES")
+
+		case clause == "GLOBAL" || clause == "EXTERNAL":
+
+		default:
+			return nil, copybookError(t.line, "unexpected %q in the entry of %s", t.text, it.Name)
+		}
+	}
+
+	if it.Picture != "" {
+		if err := it.parsePicture(sign); err != nil {
+			return nil, err
+		}
+	} else if it.Usage != UsageDisplay && it.Level != 88 {
+		return nil, copybookError(it.Line, "group %s with USAGE COMP is not supported", it.Name)
+	}
+	return it, nil
+}
+
+func (it *copybookEntry) setUsage(t copybookToken) error {
+	u, ok := copybookUsages[strings.ToUpper(t.text)]
+	if !ok {
+		return copybookError(t.line, "USAGE %s of %s is not supported", t.text, it.Name)
+	}
+	it.Usage = u
+	return nil
+}
+
+// occurs parses `OCCURS [min TO] max [TIMES] [DEPENDING ON name]` and the
+// KEY and INDEXED BY phrases, which are ignored.
+func (p *copybookParser) occurs(it *copybookEntry) error {
+	n, err := p.number("OCCURS count")
+	if err != nil {
+		return err
+	}
+	it.Occurs = n
+	if p.keyword("TO") {
+		if it.Occurs, err = p.number("OCCURS maximum"); err != nil {
+			return err
+		}
+		it.OccursMin = n
+	}
+	p.keyword("TIMES")
+
+	if p.keyword("DEPENDING") {
+		p.keyword("ON")
+		name, err := p.word("DEPENDING ON item")
+		if err != nil {
+			return err
+		}
+		it.DependingOn = name.text
+	}
+
+	for {
+		switch {
+		case p.keyword("ASCENDING", "DESCENDING"):
+			p.keyword("KEY")
+			p.keyword("IS")
+		case p.keyword("INDEXED"):
+			p.keyword("BY")
+		default:
+			return nil
+		}
+		for t := p.peek(); t.text != "." && !isCopybookClause(t) && !strings.EqualFold(t.text, "ASCENDING") &&
+			!strings.EqualFold(t.text, "DESCENDING") && !strings.EqualFold(t.text, "INDEXED"); t = p.peek() {
+			p.next()
+		}
+	}
+}
+
+// parsePicture derives the length, digits, decimals and sign of an elementary item,
+// sign is the one of the SIGN clause.
+func (it *copybookEntry) parsePicture(sign Sign) error {
+	pic := it.Picture
+	length, alphanumeric, edited, afterV := 0, false, false, false
+	for i := 0; i < len(pic); i++ {
+		ch := pic[i]
+		n := 1
+		if i+1 < len(pic) && pic[i+1] == '(' {
+			end := strings.IndexByte(pic[i:], ')')
+			if end < 0 {
+				return copybookError(it.Line, "invalid picture %s", pic)
+			}
+			count, err := strconv.Atoi(pic[i+2 : i+end])
+			if err != nil || count <= 0 {
+				return copybookError(it.Line, "invalid picture %s", pic)
+			}
+			n = count
+			i += end
+		}
+
+		switch ch {
+		case 'S':
+			it.Signed = true
+		case 'V':
+			afterV = true
+		case 'P':
+			// P scales the number without taking room, which schemas can't express:
+			// reject it rather than decode numbers off by a power of ten
+			return copybookError(it.Line, "scaling position P in the picture %s of %s is not supported", pic, it.Name)
+		case '9':
+			it.Digits += n
+			if afterV {
+				it.Decimals += n
+			}
+			length += n
+		case 'X', 'A':
+			alphanumeric = true
+			length += n
+		default:
+			edited = true
+			length += n
+		}
+	}
+
+	it.Numeric = it.Digits > 0 && !alphanumeric && !edited
+	if it.Numeric {
+		it.Sign = sign
+		switch {
+		case sign.separate():
+			if it.Usage != UsageDisplay {
+				return copybookError(it.Line, "SIGN SEPARATE of %s requires USAGE DISPLAY", it.Name)
+			}
+			length++
+		case !it.Signed:
+			it.Sign = SignNone
+		}
+	}
+
+	switch it.Usage {
+	case UsageBinary:
+		if !it.Numeric {
+			return copybookError(it.Line, "binary item %s is not numeric", it.Name)
+		}
+		length = BinaryLength(it.Digits)
+	case UsagePacked:
+		if !it.Numeric {
+			return copybookError(it.Line, "packed item %s is not numeric", it.Name)
+		}
+		length = PackedLength(it.Digits)
+	}
+	if length == 0 {
+		return copybookError(it.Line, "empty picture %s", pic)
+	}
+	it.Length = length
+	return nil
+}
+
+// Schema flattens the record named name, or the only record when name is empty,
+// into a Schema. Fields are named after their path below the record, like
+// "CUSTOMER.NAME", table occurrences are numbered from 1, like "ITEMS(2).QTY".
+// FILLER items are left out, REDEFINES and RENAMES become views of the fields
+// they overlap, and records with a table DEPENDING ON a count have no fixed length.
+func (cb *Copybook) Schema(name string) (*Schema, error) {
+	rec, err := cb.record(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &copybookFlattener{paths: map[string]string{}}
+	for _, child := range rec.Children {
+		if err := f.flatten(child, "", 0, "", "", 0); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &Schema{Fields: f.fields}
+	if !containsDependingOn(rec.Children) {
+		s.Length = rec.Size()
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (cb *Copybook) record(name string) (*CopybookItem, error) {
+	if name == "" {
+		if len(cb.Records) != 1 {
+			return nil, fmt.Errorf("%w: %d records, pick one by name", ErrInvalidCopybook, len(cb.Records))
+		}
+		return cb.Records[0], nil
+	}
+	for _, rec := range cb.Records {
+		if strings.EqualFold(rec.Name, name) {
+			return rec, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no record %s", ErrInvalidCopybook, name)
+}
+
+type copybookFlattener struct {
+	fields []SchemaField
+	paths  map[string]string // COBOL name to field name, for DEPENDING ON and RENAMES
+}
+
+// flatten adds the fields of it to the schema. shift moves tables occurrences,
+// redefines, dependsOn and occurrence are inherited from the enclosing items.
+func (f *copybookFlattener) flatten(it *CopybookItem, prefix string, shift int, redefines string, dependsOn string, occurrence int) error {
+	segment := it.Name
+	if it.IsFiller() && it.Occurs == 0 {
+		segment = ""
+	}
+	path := joinPath(prefix, segment)
+
+	if it.Redefines != "" {
+		redefines = joinPath(prefix, it.Redefines)
+	}
+
+	if it.Renames != "" {
+		base, ok := f.paths[strings.ToUpper(it.Renames)]
+		if !ok {
+			base = it.Renames
+		}
+		f.fields = append(f.fields, SchemaField{Name: path, Offset: it.Offset, Length: it.Length, Redefines: base})
+		return nil
+	}
+
+	if it.Occurs == 0 {
+		return f.add(it, path, shift, redefines, dependsOn, occurrence)
+	}
+
+	if it.DependingOn != "" {
+		counter, ok := f.paths[strings.ToUpper(it.DependingOn)]
+		if !ok {
+			return copybookError(it.Line, "%s depends on %s, which is not a preceding elementary item", it.Name, it.DependingOn)
+		}
+		dependsOn = counter
+	}
+	for i := 1; i <= it.Occurs; i++ {
+		if it.DependingOn != "" {
+			occurrence = i
+		}
+		if err := f.add(it, fmt.Sprintf("%s(%d)", path, i), shift+(i-1)*it.Length, redefines, dependsOn, occurrence); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add adds one occurrence of it.
+func (f *copybookFlattener) add(it *CopybookItem, path string, shift int, redefines string, dependsOn string, occurrence int) error {
+	if it.Occurs == 0 && !it.IsFiller() {
+		f.paths[strings.ToUpper(it.Name)] = path
+	}
+
+	if it.IsGroup() {
+		for _, child := range it.Children {
+			if err := f.flatten(child, path, shift, redefines, dependsOn, occurrence); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if it.IsFiller() {
+		return nil
+	}
+
+	field := SchemaField{
+		Name:       path,
+		Offset:     it.Offset + shift,
+		Length:     it.Length,
+		Usage:      it.Usage,
+		Sign:       it.Sign,
+		Redefines:  redefines,
+		DependsOn:  dependsOn,
+		Occurrence: occurrence,
+	}
+	if it.Numeric {
+		field.Type = FieldTypeInt
+		if it.Decimals > 0 {
+			field.Type = FieldTypeFloat
+			field.Decimals = it.Decimals
+		}
+		field.Alignment = AlignmentTypeRight
+	}
+	f.fields = append(f.fields, field)
+	return nil
+}
+
+func joinPath(prefix string, name string) string {
+	switch {
+	case prefix == "":
+		return name
+	case name == "":
+		return prefix
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,227 @@
+package fixedlength
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testCopybook = `
+000100* CUSTOMER MASTER RECORD                                          CUSTREC
+000200 01  CUSTOMER-RECORD.                                             CUSTREC
+000300     05  CUST-ID             PIC 9(6).                            CUSTREC
+000400     05  CUST-NAME           PIC X(10).                           CUSTREC
+000500     05  CUST-STATUS         PIC X.                               CUSTREC
+000600         88  STATUS-ACTIVE   VALUE 'A'.                           CUSTREC
+000700         88  STATUS-CLOSED   VALUES ARE 'C' 'X'.                  CUSTREC
+000800     05  FILLER              PIC X(2).                            CUSTREC
+000900     05  CUST-BALANCE        PIC S9(5)V99 COMP-3.                 CUSTREC
+001000     05  CUST-SINCE.                                              CUSTREC
+001100         10  SINCE-YEAR      PIC 9(4).                            CUSTREC
+001200         10  SINCE-MONTH     PIC 99.                              CUSTREC
+001300     05  CUST-SINCE-N REDEFINES CUST-SINCE PIC 9(6).              CUSTREC
+001400     05  CUST-PHONES OCCURS 2 TIMES INDEXED BY PH-IDX.            CUSTREC
+001500         10  PHONE-TYPE      PIC X.                               CUSTREC
+001600         10  PHONE-NUMBER    PIC X(8).                            CUSTREC
+001700     05  CUST-ORDERS         PIC S9(4) USAGE IS COMP.             CUSTREC
+001800 66  CUST-KEY RENAMES CUST-ID THRU CUST-NAME.                     CUSTREC
+`
+
+func TestParseCopybook(t *testing.T) {
+	cb, err := ParseCopybook(strings.NewReader(testCopybook))
+	require.NoError(t, err)
+	require.Len(t, cb.Records, 1)
+
+	rec := cb.Records[0]
+	require.Equal(t, "CUSTOMER-RECORD", rec.Name)
+	require.Equal(t, 49, rec.Length)
+	require.Len(t, rec.Children, 10)
+
+	status := rec.Children[2]
+	require.Equal(t, []CopybookCondition{
+		{Name: "STATUS-ACTIVE", Values: []string{"A"}},
+		{Name: "STATUS-CLOSED", Values: []string{"C", "X"}},
+	}, status.Conditions)
+
+	balance := rec.Children[4]
+	require.Equal(t, UsagePacked, balance.Usage)
+	require.True(t, balance.Signed)
+	require.True(t, balance.Numeric)
+	require.Equal(t, 7, balance.Digits)
+	require.Equal(t, 2, balance.Decimals)
+	require.Equal(t, 19, balance.Offset)
+	require.Equal(t, 4, balance.Length)
+
+	phones := rec.Children[7]
+	require.Equal(t, 29, phones.Offset)
+	require.Equal(t, 9, phones.Length)
+	require.Equal(t, 18, phones.Size())
+	require.Equal(t, 2, phones.Occurs)
+
+	key := rec.Children[9]
+	require.Equal(t, 66, key.Level)
+	require.Equal(t, 0, key.Offset)
+	require.Equal(t, 16, key.Length)
+}
+
+func TestCopybookSchema(t *testing.T) {
+	s, err := LoadCopybook(strings.NewReader(testCopybook))
+	require.NoError(t, err)
+	require.Equal(t, 49, s.Length)
+
+	var names []string
+	for _, f := range s.Fields {
+		names = append(names, f.Name)
+	}
+	require.Equal(t, []string{
+		"CUST-ID", "CUST-NAME", "CUST-STATUS", "CUST-BALANCE",
+		"CUST-SINCE.SINCE-YEAR", "CUST-SINCE.SINCE-MONTH", "CUST-SINCE-N",
+		"CUST-PHONES(1).PHONE-TYPE", "CUST-PHONES(1).PHONE-NUMBER",
+		"CUST-PHONES(2).PHONE-TYPE", "CUST-PHONES(2).PHONE-NUMBER",
+		"CUST-ORDERS", "CUST-KEY",
+	}, names)
+
+	require.Equal(t, SchemaField{Name: "CUST-BALANCE", Offset: 19, Length: 4, Type: FieldTypeFloat, Decimals: 2, Alignment: AlignmentTypeRight, Usage: UsagePacked}, s.Fields[3])
+	require.Equal(t, SchemaField{Name: "CUST-SINCE-N", Offset: 23, Length: 6, Type: FieldTypeInt, Alignment: AlignmentTypeRight, Sign: SignNone, Redefines: "CUST-SINCE"}, s.Fields[6])
+	require.Equal(t, 38, s.Fields[9].Offset)
+	require.Equal(t, SchemaField{Name: "CUST-ORDERS", Offset: 47, Length: 2, Type: FieldTypeInt, Alignment: AlignmentTypeRight, Usage: UsageBinary}, s.Fields[11])
+	require.Equal(t, SchemaField{Name: "CUST-KEY", Offset: 0, Length: 16, Redefines: "CUST-ID"}, s.Fields[12])
+
+	cfg := DefaultConfig()
+	cfg.PositionUnit = PositionUnitByte
+	codec := NewCodec(cfg)
+
+	data := "000042ACME      A  \x01\x23\x45\x6D202403H55501234M55505678\x00\x07"
+	m, err := codec.UnmarshalMap([]byte(data), s)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), m["CUST-ID"])
+	require.Equal(t, -1234.56, m["CUST-BALANCE"])
+	require.Equal(t, int64(3), m["CUST-SINCE.SINCE-MONTH"])
+	require.Equal(t, int64(202403), m["CUST-SINCE-N"])
+	require.Equal(t, "55505678", m["CUST-PHONES(2).PHONE-NUMBER"])
+	require.Equal(t, int64(7), m["CUST-ORDERS"])
+	require.Equal(t, "000042ACME", m["CUST-KEY"])
+
+	delete(m, "CUST-SINCE-N")
+	delete(m, "CUST-KEY")
+	res, err := codec.MarshalMap(s, m)
+	require.NoError(t, err)
+	require.Equal(t, data, string(res))
+}
+
+const testCopybookODO = `
+       01  ORDER.
+           05  ORDER-ID      PIC X(4).
+           05  LINE-COUNT    PIC 9.
+           05  ORDER-LINES OCCURS 1 TO 3 TIMES
+                   DEPENDING ON LINE-COUNT.
+               10  SKU       PIC X(3).
+               10  QTY       PIC 9(2).
+`
+
+func TestCopybookOccursDependingOn(t *testing.T) {
+	s, err := LoadCopybook(strings.NewReader(testCopybookODO))
+	require.NoError(t, err)
+	require.Equal(t, 0, s.Length)
+	require.Len(t, s.Fields, 8)
+	require.Equal(t, SchemaField{Name: "ORDER-LINES(3).QTY", Offset: 18, Length: 2, Type: FieldTypeInt, Alignment: AlignmentTypeRight, Sign: SignNone, DependsOn: "LINE-COUNT", Occurrence: 3}, s.Fields[7])
+
+	m, err := UnmarshalMap([]byte("A0012AAA01BBB22"), s)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		"ORDER-ID":           "A001",
+		"LINE-COUNT":         int64(2),
+		"ORDER-LINES(1).SKU": "AAA",
+		"ORDER-LINES(1).QTY": int64(1),
+		"ORDER-LINES(2).SKU": "BBB",
+		"ORDER-LINES(2).QTY": int64(22),
+	}, m)
+}
+
+func TestCopybookFreeFormat(t *testing.T) {
+	src := `*> free format, without a record level
+05 CODE PIC XX. *> record code
+05 AMOUNT PIC 9(3)V9 SIGN IS LEADING SEPARATE.
+05 FILLER PIC X(3) VALUE SPACES.
+05 RATE PIC V99.`
+	s, err := LoadCopybook(strings.NewReader(src))
+	require.NoError(t, err)
+	require.Equal(t, 12, s.Length)
+	require.Equal(t, SchemaField{Name: "AMOUNT", Offset: 2, Length: 5, Type: FieldTypeFloat, Decimals: 1, Alignment: AlignmentTypeRight, Sign: SignLeading}, s.Fields[1])
+	require.Equal(t, 10, s.Fields[2].Offset)
+
+	// indented free format lines keep their level numbers
+	s, err = LoadCopybook(strings.NewReader("01 REC.\n    05 A PIC X(3).\n      05 B PIC 9.\n"))
+	require.NoError(t, err)
+	require.Equal(t, 4, s.Length)
+	require.Equal(t, "B", s.Fields[1].Name)
+}
+
+func TestCopybookSign(t *testing.T) {
+	src := `       01  R.
+           05  AMOUNT  PIC S9(3) SIGN TRAILING SEPARATE.
+           05  COUNT   PIC 9 COMP-3.
+           05  TOTAL   PIC S9 COMP-3.`
+	s, err := LoadCopybook(strings.NewReader(src))
+	require.NoError(t, err)
+	require.Equal(t, 6, s.Length)
+
+	cfg := DefaultConfig()
+	cfg.PositionUnit = PositionUnitByte
+	codec := NewCodec(cfg)
+
+	m, err := codec.UnmarshalMap([]byte{'0', '1', '2', '-', 0x7F, 0x7C}, s)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"AMOUNT": int64(-12), "COUNT": int64(7), "TOTAL": int64(7)}, m)
+
+	res, err := codec.MarshalMap(s, m)
+	require.NoError(t, err)
+	require.Equal(t, []byte{'0', '1', '2', '-', 0x7F, 0x7C}, res)
+
+	_, err = codec.MarshalMap(s, map[string]any{"COUNT": -7})
+	require.ErrorContains(t, err, "unsigned number -7 is negative")
+}
+
+func TestCopybookErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"empty", "      * nothing here", "no data description entries"},
+		{"unknown clause", "01 R. 05 A PIC X FOO.", `line 1: unexpected "FOO"`},
+		{"bad picture", "01 R. 05 A PIC X(.", "invalid picture"},
+		{"floating point", "01 R. 05 A COMP-1.", "USAGE COMP-1 of A is not supported"},
+		{"packed text", "01 R. 05 A PIC X(3) COMP-3.", "packed item A is not numeric"},
+		{"below elementary", "01 R. 05 A PIC X. 10 B PIC X.", "B is below the elementary item A"},
+		{"unknown redefines", "01 R. 05 A PIC X. 05 B REDEFINES C PIC X.", "B redefines C"},
+		{"after table", "01 R. 05 N PIC 9. 05 T PIC X OCCURS 1 TO 2 DEPENDING ON N. 05 Z PIC X.", "Z follows a table"},
+		{"unknown counter", "01 R. 05 T PIC X OCCURS 1 TO 2 DEPENDING ON N.", "T depends on N"},
+		{"missing period", "01 R. 05 A PIC", "missing picture"},
+		{"scaling position", "01 R. 05 A PIC 9(3)P(2).", "scaling position P in the picture 9(3)P(2) of A is not supported"},
+		{"leading scaling position", "01 R. 05 A PIC PP9.", "scaling position P in the picture PP9 of A"},
+		{"leading overpunch", "01 R. 05 A PIC S9 SIGN LEADING.", "SIGN LEADING of A is only supported with SEPARATE"},
+		{"packed separate sign", "01 R. 05 A PIC S9 COMP-3 SIGN SEPARATE.", "SIGN SEPARATE of A requires USAGE DISPLAY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadCopybook(strings.NewReader(tt.src))
+			require.ErrorIs(t, err, ErrInvalidCopybook)
+			require.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestCopybookRecords(t *testing.T) {
+	cb, err := ParseCopybook(strings.NewReader("01 HEADER. 05 H-TYPE PIC X. 01 DETAIL. 05 D-TYPE PIC X. 05 D-AMOUNT PIC 9(4)."))
+	require.NoError(t, err)
+
+	_, err = cb.Schema("")
+	require.ErrorIs(t, err, ErrInvalidCopybook)
+
+	s, err := cb.Schema("detail")
+	require.NoError(t, err)
+	require.Equal(t, 5, s.Length)
+	require.Equal(t, "D-AMOUNT", s.Fields[1].Name)
+}
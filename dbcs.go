@@ -0,0 +1,276 @@
+package fixedlength
+
+import (
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	shiftOut = 0x0E // SO, starts double byte characters
+	shiftIn  = 0x0F // SI, back to single byte characters
+)
+
+// DoubleByteTable maps two byte codes of a double byte character set to Unicode.
+type DoubleByteTable interface {
+	DecodeDouble(hi, lo byte) (rune, bool)
+	EncodeDouble(r rune) (hi, lo byte, ok bool)
+}
+
+// jisTable is JIS X 0208 with both bytes in 0x21-0x7E.
+type jisTable struct{}
+
+// TableJISX0208 is the JIS X 0208 double byte table, used between SO and SI
+// by systems exchanging 7-bit JIS data.
+var TableJISX0208 DoubleByteTable = jisTable{}
+
+var (
+	jisReverseOnce sync.Once
+	jisReverse     map[rune]uint16 // unicode to table index
+)
+
+func jisIndex(r rune) (int, bool) {
+	jisReverseOnce.Do(func() {
+		jisReverse = make(map[rune]uint16, 7000)
+		for i, u := range jisx0208Table {
+			if u != 0 {
+				jisReverse[rune(u)] = uint16(i)
+			}
+		}
+	})
+
+	i, ok := jisReverse[r]
+	return int(i), ok
+}
+
+func (jisTable) DecodeDouble(hi, lo byte) (rune, bool) {
+	if hi < 0x21 || hi > 0x7E || lo < 0x21 || lo > 0x7E {
+		return utf8.RuneError, false
+	}
+
+	u := jisx0208Table[int(hi-0x21)*94+int(lo-0x21)]
+	if u == 0 {
+		return utf8.RuneError, false
+	}
+	return rune(u), true
+}
+
+func (jisTable) EncodeDouble(r rune) (byte, byte, bool) {
+	i, ok := jisIndex(r)
+	if !ok {
+		return 0, 0, false
+	}
+	return byte(i/94 + 0x21), byte(i%94 + 0x21), true
+}
+
+// sosiCharset mixes single byte characters with double byte characters
+// framed by SO and SI, as in host DBCS extracts.
+type sosiCharset struct {
+	name   string
+	single Charset
+	double DoubleByteTable
+}
+
+// NewSOSICharset combines a single byte charset with a double byte table.
+// Double byte characters are written between SO (0x0E) and SI (0x0F), so every switch
+// costs a byte: 20 bytes hold up to 9 double byte characters.
+func NewSOSICharset(name string, single Charset, double DoubleByteTable) Charset {
+	return &sosiCharset{name: name, single: single, double: double}
+}
+
+func (cs *sosiCharset) Name() string {
+	return cs.name
+}
+
+func (cs *sosiCharset) Decode(data []byte) (string, error) {
+	res := make([]rune, 0, len(data))
+	double := false
+	start := 0 // start of the pending single byte run
+
+	flush := func(end int) error {
+		if end <= start {
+			return nil
+		}
+		str, err := cs.single.Decode(data[start:end])
+		if err != nil {
+			return err
+		}
+		res = append(res, []rune(str)...)
+		return nil
+	}
+
+	for i := 0; i < len(data); {
+		switch {
+		case data[i] == shiftOut:
+			if err := flush(i); err != nil {
+				return "", err
+			}
+			double = true
+			i++
+		case data[i] == shiftIn:
+			double = false
+			i++
+			start = i
+		case double:
+			if i+1 >= len(data) {
+				res = append(res, utf8.RuneError)
+				i++
+				continue
+			}
+			r, _ := cs.double.DecodeDouble(data[i], data[i+1])
+			res = append(res, r)
+			i += 2
+		default:
+			i++
+		}
+	}
+
+	if !double {
+		if err := flush(len(data)); err != nil {
+			return "", err
+		}
+	}
+
+	return string(res), nil
+}
+
+func (cs *sosiCharset) Encode(str string, unmappable UnmappableType, replacement byte) ([]byte, error) {
+	res := make([]byte, 0, len(str))
+	double := false
+
+	for _, r := range str {
+		if b, err := cs.single.Encode(string(r), UnmappableTypeError, 0); err == nil {
+			if double {
+				res = append(res, shiftIn)
+				double = false
+			}
+			res = append(res, b...)
+			continue
+		}
+
+		if hi, lo, ok := cs.double.EncodeDouble(r); ok {
+			if !double {
+				res = append(res, shiftOut)
+				double = true
+			}
+			res = append(res, hi, lo)
+			continue
+		}
+
+		b, ok, err := unmappableByte(cs.name, r, unmappable, replacement)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			if double {
+				res = append(res, shiftIn)
+				double = false
+			}
+			res = append(res, b)
+		}
+	}
+
+	if double {
+		res = append(res, shiftIn)
+	}
+
+	return res, nil
+}
+
+// shiftJISCharset is the PC Shift-JIS encoding: ASCII, half width katakana
+// and JIS X 0208 characters encoded with a lead byte.
+type shiftJISCharset struct{}
+
+var CharsetShiftJIS Charset = shiftJISCharset{}
+
+func (shiftJISCharset) Name() string {
+	return "Shift-JIS"
+}
+
+func isShiftJISLead(b byte) bool {
+	return (b >= 0x81 && b <= 0x9F) || (b >= 0xE0 && b <= 0xEF)
+}
+
+func (shiftJISCharset) Decode(data []byte) (string, error) {
+	res := make([]rune, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+		switch {
+		case b < 0x80:
+			res = append(res, rune(b))
+		case b >= 0xA1 && b <= 0xDF:
+			res = append(res, rune(0xFF61+int(b-0xA1)))
+		case isShiftJISLead(b) && i+1 < len(data):
+			res = append(res, decodeShiftJISPair(b, data[i+1]))
+			i++
+		default:
+			res = append(res, utf8.RuneError)
+		}
+	}
+	return string(res), nil
+}
+
+func decodeShiftJISPair(lead, trail byte) rune {
+	if trail < 0x40 || trail > 0xFC || trail == 0x7F {
+		return utf8.RuneError
+	}
+
+	l := int(lead)
+	if l >= 0xE0 {
+		l -= 0x40
+	}
+	row := (l-0x80)*2 - 1
+	cell := int(trail) - 0x3F
+	if trail > 0x7F {
+		cell--
+	}
+	if trail >= 0x9F {
+		row++
+		cell = int(trail) - 0x9E
+	}
+
+	u := jisx0208Table[(row-1)*94+cell-1]
+	if u == 0 {
+		return utf8.RuneError
+	}
+	return rune(u)
+}
+
+func (shiftJISCharset) Encode(str string, unmappable UnmappableType, replacement byte) ([]byte, error) {
+	res := make([]byte, 0, len(str))
+	for _, r := range str {
+		switch {
+		case r < 0x80:
+			res = append(res, byte(r))
+			continue
+		case r >= 0xFF61 && r <= 0xFF9F:
+			res = append(res, byte(r-0xFF61+0xA1))
+			continue
+		}
+
+		if i, ok := jisIndex(r); ok {
+			row, cell := i/94+1, i%94+1
+			lead := (row+1)/2 + 0x80
+			if lead >= 0xA0 {
+				lead += 0x40
+			}
+			trail := cell + 0x9E
+			if row%2 == 1 {
+				trail = cell + 0x3F
+				if trail >= 0x7F {
+					trail++
+				}
+			}
+			res = append(res, byte(lead), byte(trail))
+			continue
+		}
+
+		b, ok, err := unmappableByte("Shift-JIS", r, unmappable, replacement)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			res = append(res, b)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,72 @@
+package fixedlength
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShiftJIS(t *testing.T) {
+	data := []byte{0x93, 0xFA, 0x96, 0x7B, 0x8C, 0xEA, 0xB1, 'A'}
+
+	text, err := CharsetShiftJIS.Decode(data)
+	require.NoError(t, err)
+	require.Equal(t, "日本語ｱA", text)
+
+	res, err := CharsetShiftJIS.Encode(text, UnmappableTypeError, '?')
+	require.NoError(t, err)
+	require.Equal(t, data, res)
+
+	_, err = CharsetShiftJIS.Encode("€", UnmappableTypeError, '?')
+	require.Error(t, err)
+}
+
+func TestSOSICharset(t *testing.T) {
+	cs := NewSOSICharset("JIS-SOSI", CharsetISO88591, TableJISX0208)
+
+	res, err := cs.Encode("ab漢字c", UnmappableTypeError, '?')
+	require.NoError(t, err)
+	require.Equal(t, []byte{'a', 'b', shiftOut, 0x34, 0x41, 0x3B, 0x7A, shiftIn, 'c'}, res)
+
+	text, err := cs.Decode(res)
+	require.NoError(t, err)
+	require.Equal(t, "ab漢字c", text)
+
+	res, err = cs.Encode("漢€", UnmappableTypeReplace, '?')
+	require.NoError(t, err)
+	require.Equal(t, []byte{shiftOut, 0x34, 0x41, shiftIn, '?'}, res)
+}
+
+func TestCodecDBCSField(t *testing.T) {
+	RegisterCharset("test-sosi", NewSOSICharset("test-sosi", CharsetISO88591, TableJISX0208))
+
+	type rec struct {
+		Name string `range:"0,20" charset:"test-sosi"`
+		Code string `range:"20,22"`
+	}
+
+	cfg := DefaultConfig()
+	cfg.TypePrefixLength = 0
+	cfg.PositionUnit = PositionUnitByte
+	c := NewCodec(cfg)
+
+	nine := strings.Repeat("漢", 9)
+	res, err := c.Marshal(&rec{Name: nine, Code: "JP"})
+	require.NoError(t, err)
+	require.Len(t, res, 22)
+	require.Equal(t, byte(shiftOut), res[0])
+	require.Equal(t, byte(shiftIn), res[19])
+
+	var v rec
+	require.NoError(t, c.Unmarshal(res, &v))
+	require.Equal(t, rec{Name: nine, Code: "JP"}, v)
+
+	_, err = c.Marshal(&rec{Name: strings.Repeat("漢", 10), Code: "JP"})
+	require.Error(t, err)
+
+	t.Run("rune positions", func(t *testing.T) {
+		_, err := Marshal(&rec{Name: "a", Code: "JP"})
+		require.Error(t, err)
+	})
+}
@@ -1,11 +1,11 @@
 package fixedlength
 
 import (
+	"encoding"
 	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
-	"strings"
 )
 
 var (
@@ -17,9 +17,42 @@ var (
 
 // setFieldValue sets the value for a struct field using reflection.
 func setFieldValue(field reflect.Value, value string, tag tag) error {
+	return defaultCodec().setFieldValue(field, value, tag)
+}
+
+func (c *Codec) setFieldValue(field reflect.Value, value string, tag tag) error {
+	// custom unmarshalers win over the kind, so named types like `type Cents int64` work
+	if conv, ok := c.converterFor(field.Type()); ok && field.CanSet() {
+		v, err := conv.decode(value, c.fieldInfo(tag))
+		if err != nil {
+			return err
+		}
+		field.Set(v)
+		return nil
+	}
+
+	if isFormattedTime(field, tag) {
+		return parseTime(field, value, tag)
+	}
+
+	if fum, ok := interfaceOf[FieldUnmarshaler](field, true); ok {
+		return fum.UnmarshalFixedField(c.fieldInfo(tag), []byte(value))
+	}
+
+	if um, ok := unmarshalerOf(field); ok {
+		return um.Unmarshal([]byte(value))
+	}
+
+	if tum, ok := interfaceOf[encoding.TextUnmarshaler](field, true); ok {
+		return tum.UnmarshalText([]byte(value))
+	}
 
 	switch field.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value, err := decodeSign(value, tag)
+		if err != nil {
+			return err
+		}
 
 		cValue, err := ConvertEBCDICToAsciiNumber(value, tag.decimals)
 		if err != nil {
@@ -33,6 +66,11 @@ func setFieldValue(field reflect.Value, value string, tag tag) error {
 		field.SetInt(intVal)
 
 	case reflect.Float32, reflect.Float64:
+		value, err := decodeSign(value, tag)
+		if err != nil {
+			return err
+		}
+
 		cValue, err := ConvertEBCDICToAsciiNumber(value, tag.decimals)
 		if err != nil {
 			return err
@@ -55,11 +93,6 @@ func setFieldValue(field reflect.Value, value string, tag tag) error {
 		field.SetBool(boolVal)
 
 	default:
-		if implementsUnmarshaler(field) {
-			um := field.Addr().Interface().(Unmarshaler)
-			return um.Unmarshal([]byte(value))
-		}
-
 		return fmt.Errorf("%w: %s", ErrUnsupportedKind, field.Kind())
 	}
 	return nil
@@ -96,6 +129,22 @@ func implementsUnmarshaler(val reflect.Value) bool {
 	return false
 }
 
+// decodesItself reports whether a value is decoded as a whole by a converter
+// or one of the unmarshaler interfaces, rather than field by field.
+func (c *Codec) decodesItself(val reflect.Value) bool {
+	if _, ok := c.converterFor(val.Type()); ok {
+		return true
+	}
+	return implementsUnmarshaler(val) || implementsInterface[FieldUnmarshaler](val) ||
+		implementsInterface[encoding.TextUnmarshaler](val)
+}
+
+// unmarshalerOf returns the Unmarshaler of a settable value of any kind.
+// Nil pointers to unmarshalers are allocated first.
+func unmarshalerOf(val reflect.Value) (Unmarshaler, bool) {
+	return interfaceOf[Unmarshaler](val, true)
+}
+
 // InvalidUnmarshalError describes an invalid argument passed to [Unmarshal].
 // (The argument to [Unmarshal] must be a non-nil pointer.)
 type InvalidUnmarshalError struct {
@@ -115,30 +164,139 @@ func (e InvalidUnmarshalError) Error() string {
 // Unmarshal parses the given string into the provided struct v.
 // v must be a pointer to a struct, and its fields should be tagged with `range:"<start>,<end>"`
 // where start and end are the lower and upper bounds of the segment in the string.
-// Unmarshal will parse nested structs recursively.
+// Alternatively `pos:"<first>,<last>"` takes 1-based inclusive positions, and `len:"<n>"`
+// places the field right after the previous one.
+// Blank fields tagged with `default:"<value>"` are decoded from that value instead,
+// blank fields flagged `flags:"omitzero"` are set to their zero value.
+// time.Time fields tagged `format:"<layout>"` are parsed with that layout, blank ones
+// are left zero.
+// Unmarshal will parse nested structs recursively, and the elements of arrays one
+// after the other.
 func Unmarshal(data []byte, v any) error {
+	return defaultCodec().Unmarshal(data, v)
+}
+
+// Unmarshal decodes data like the package level Unmarshal, using the codec configuration.
+func (c *Codec) Unmarshal(data []byte, v any) error {
 	// Validate that v is a pointer to a struct
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Pointer || rv.IsNil() {
 		return InvalidUnmarshalError{reflect.TypeOf(v)}
 	}
 
-	// convert to runes since we use utf-8 here
-	runes := []rune(string(data))
+	rec, err := parseStructRecordTag(rv.Elem().Type())
+	if err != nil {
+		return err
+	}
+
+	rr, err := c.newRecordReader(data)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.unmarshalStruct(rr, rv.Elem(), structPath(rv.Elem().Type()), c.recordStart(rec))
+	return err
+}
+
+// unmarshalStruct decodes the fields of sv, nested structs included, then runs its
+// AfterUnmarshal hook. Fields of embedded structs are promoted into the layout of sv,
+// nil embedded pointers are allocated. nextPos is where `len` fields start, the position
+// after the last field is returned with the tags of the decoded fields.
+func (c *Codec) unmarshalStruct(rr *recordReader, sv reflect.Value, path string, nextPos int) (int, []tag, error) {
+	own := layoutGroup{path: path}
+	var promoted []layoutGroup
+	var tags []tag
+	layout := newRedefinesLayout(sv.Type())
+
 	// Iterate over struct fields to map segment names to fields
-	for i := 0; i < rv.Elem().NumField(); i++ {
-		field := rv.Elem().Field(i)
+	for i := 0; i < sv.NumField(); i++ {
+		field := sv.Field(i)
+		sf := sv.Type().Field(i)
+
+		// record marker fields carry no data
+		if isRecordMarker(sf) {
+			continue
+		}
+
+		var err error
+		nextPos, err = layout.next(sf, nextPos)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		// views whose condition doesn't hold are left alone
+		selected, err := viewSelected(sv, sf)
+		if err != nil {
+			return 0, nil, err
+		}
+		if !selected {
+			nextPos, err = c.skipField(sf, nextPos)
+			if err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+
+		if isEmbeddedStructPointer(sf) {
+			if field.IsNil() {
+				if !field.CanSet() {
+					continue
+				}
+				field.Set(reflect.New(sf.Type.Elem()))
+			}
+			field = field.Elem()
+		}
 
 		// Recursively parse the struct
-		if field.Kind() == reflect.Struct && !implementsUnmarshaler(field) {
-			if err := Unmarshal(data, field.Addr().Interface()); err != nil {
-				return err
+		if field.Kind() == reflect.Struct && !c.decodesItself(field) && !isLiteralField(sf) {
+			nested, nestedTags, err := c.unmarshalStruct(rr, field, path+"."+sf.Name, nextPos)
+			if err != nil {
+				return 0, nil, err
+			}
+			nextPos = nested
+			tags = append(tags, nestedTags...)
+			if sf.Anonymous {
+				promoted = append(promoted, layoutGroup{path: path + "." + sf.Name, tags: nestedTags})
 			}
 
 			continue
 		}
 
-		tag, err := parseFieldTag(rv.Elem().Type().Field(i).Tag)
+		if isArrayLayout(field) && !c.decodesItself(field) && !isLiteralField(sf) {
+			for k := 0; k < field.Len(); k++ {
+				elem := field.Index(k)
+				name := fmt.Sprintf("%s[%d]", sf.Name, k)
+				if elem.Kind() == reflect.Struct && !c.decodesItself(elem) {
+					nested, nestedTags, err := c.unmarshalStruct(rr, elem, path+"."+name, nextPos)
+					if err != nil {
+						return 0, nil, err
+					}
+					nextPos = nested
+					tags = append(tags, nestedTags...)
+					continue
+				}
+
+				tag, err := parseFieldTagAt(sf.Tag, nextPos)
+				if err != nil {
+					if errors.Is(err, ErrTagEmpty) || tag.flags.optional {
+						break
+					}
+					return 0, nil, fmt.Errorf("failed to parse tag %s (%s) : %w", name, tag, err)
+				}
+				nextPos = tag.toPos
+				tag.name = name
+				if !isView(sf) {
+					own.tags = append(own.tags, tag)
+				}
+				tags = append(tags, tag)
+				if err := c.unmarshalField(rr, sv, elem, tag, path); err != nil {
+					return 0, nil, err
+				}
+			}
+			continue
+		}
+
+		tag, err := parseFieldTagAt(sf.Tag, nextPos)
 		if err != nil {
 			if errors.Is(err, ErrTagEmpty) {
 				continue
@@ -147,27 +305,165 @@ func Unmarshal(data []byte, v any) error {
 			if tag.flags.optional {
 				continue
 			}
-			return fmt.Errorf("failed to parse tag %s (%s) : %w", rv.Elem().Type().Field(i).Name, tag, err)
+			return 0, nil, fmt.Errorf("failed to parse tag %s (%s) : %w", sf.Name, tag, err)
+		}
+		nextPos = tag.toPos
+		tag.name = sf.Name
+		if !isView(sf) {
+			own.tags = append(own.tags, tag)
+		}
+		tags = append(tags, tag)
+
+		if err := c.unmarshalField(rr, sv, field, tag, path); err != nil {
+			return 0, nil, err
 		}
+	}
+
+	nextPos = layout.done(nextPos)
+
+	if err := checkPromotedOverlaps(own, promoted); err != nil {
+		return 0, nil, err
+	}
+
+	if err := runAfterUnmarshal(sv, path); err != nil {
+		return 0, nil, err
+	}
 
-		l := len(runes)
-		err = tag.Validate(l)
+	return nextPos, tags, nil
+}
+
+// unmarshalField decodes the field described by t into field, sv is the struct holding it.
+func (c *Codec) unmarshalField(rr *recordReader, sv reflect.Value, field reflect.Value, tag tag, path string) error {
+	if err := c.checkFieldTag(tag); err != nil {
+		return fmt.Errorf("invalid tag %s (%s) : %w", tag.name, tag, err)
+	}
+
+	if err := tag.Validate(rr.Len()); err != nil {
+		if tag.flags.optional {
+			return nil
+		}
+		return fmt.Errorf("failed to validate tag %s (%s) : %w", tag.name, tag, err)
+	}
+
+	if tag.discriminator != "" {
+		if err := c.unmarshalVariant(rr, sv, field, tag, path+"."+tag.name); err != nil {
+			if tag.flags.optional {
+				return nil
+			}
+			return fmt.Errorf("failed to decode variant %s (%s) : %w", tag.name, tag, err)
+		}
+		return nil
+	}
+
+	if tag.usage != UsageDisplay {
+		num, err := decodeUsage(rr.raw(tag), tag)
+		if err == nil {
+			err = c.setFieldValue(field, num, tag)
+		}
 		if err != nil {
 			if tag.flags.optional {
-				continue
+				return nil
 			}
-			return fmt.Errorf("failed to validate tag %s (%s) : %w", rv.Elem().Type().Field(i).Name, tag, err)
+			return fmt.Errorf("failed to set field value %s (%s) : %w", tag.name, tag, err)
 		}
+		return nil
+	}
 
-		value := strings.TrimSpace(string(runes[tag.fromPos:tag.toPos]))
+	raw, err := rr.field(tag)
+	if err != nil {
+		return fmt.Errorf("failed to decode field %s (%s) : %w", tag.name, tag, err)
+	}
 
-		if err := setFieldValue(field, value, tag); err != nil {
+	if tag.constant != nil || tag.flags.filler {
+		if err := c.checkLiteral(raw, tag); err != nil {
 			if tag.flags.optional {
-				continue
+				return nil
 			}
-			return fmt.Errorf("failed to set field value %s (%s) : %w", rv.Elem().Type().Field(i).Name, tag, err)
+			return fmt.Errorf("invalid field %s (%s) : %w", tag.name, tag, err)
+		}
+		if tag.flags.filler || !holdsValue(field) {
+			return nil
 		}
 	}
 
+	value := c.trimField(raw, field, tag)
+	if value == "" {
+		if tag.defaultValue != nil {
+			value = *tag.defaultValue
+		} else if tag.flags.omitzero {
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+	}
+
+	if err := c.setFieldValue(field, value, tag); err != nil {
+		if tag.flags.optional {
+			return nil
+		}
+		return fmt.Errorf("failed to set field value %s (%s) : %w", tag.name, tag, err)
+	}
 	return nil
 }
+
+// withoutRightTrim returns trim restricted to the left side.
+func withoutRightTrim(trim TrimType) TrimType {
+	switch trim {
+	case TrimTypeBoth:
+		return TrimTypeLeft
+	case TrimTypeRight:
+		return TrimTypeNone
+	}
+	return trim
+}
+
+// trimField removes the padding written by Marshal. Unless the trim tag says otherwise,
+// fields are trimmed on the padded side of their declared alignment. Strings fall back to
+// the codec alignment, display numbers to the side Marshal pads them on, everything else
+// to both sides.
+// The cutset defaults to the field filler, or the codec filler for strings; numbers are
+// trimmed of the filler Marshal pads them with, but keep their leading zeroes for the
+// number conversion. A digit filler is never trimmed from the right of a number.
+func (c *Codec) trimField(value string, field reflect.Value, tag tag) string {
+	trim := tag.trim
+	if trim == TrimTypeDefault {
+		switch {
+		case isNumberKind(field.Kind()) && tag.usage == UsageDisplay:
+			align := c.config.AlignmentType
+			if tag.align != AlignmentTypeNone {
+				align = tag.align
+			}
+			align, _ = c.numberPadding(tag, align)
+			trim = trimTypeForAlignment(align)
+		case tag.align != AlignmentTypeNone:
+			trim = trimTypeForAlignment(tag.align)
+		case field.Kind() == reflect.String:
+			trim = trimTypeForAlignment(c.config.AlignmentType)
+		default:
+			trim = TrimTypeBoth
+		}
+	}
+
+	if tag.cutset != "" {
+		return TrimString(value, trim, tag.cutset)
+	}
+
+	filler := -1
+	if tag.fill >= 0 {
+		filler = tag.fill
+	} else if field.Kind() == reflect.String {
+		filler = int(c.config.Filler)
+	} else if isNumberKind(field.Kind()) && tag.usage == UsageDisplay {
+		if _, pad := c.numberPadding(tag, tag.align); pad != '0' {
+			filler = int(pad)
+		}
+	}
+	if filler < 0 {
+		return TrimString(value, trim, "")
+	}
+
+	if tag.trim == TrimTypeDefault && isNumberKind(field.Kind()) && filler >= '0' && filler <= '9' {
+		// trailing digits belong to the number, whatever the alignment
+		trim = withoutRightTrim(trim)
+	}
+	return c.trimFiller(value, trim, tag, byte(filler))
+}
@@ -354,3 +354,169 @@ func TestUnmarshalWithDecimals(t *testing.T) {
 		})
 	}
 }
+
+func TestUnmarshalSkipsRecordMarker(t *testing.T) {
+	type rec struct {
+		_ struct{} `record:"length=6"`
+		A string   `range:"2,6"`
+	}
+
+	var v rec
+	if err := Unmarshal([]byte("01abcd"), &v); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if v.A != "abcd" {
+		t.Errorf("Expected v.A to be 'abcd', got '%s'", v.A)
+	}
+}
+
+func TestUnmarshalTrim(t *testing.T) {
+	type rec struct {
+		Text   string `range:"0,6"`
+		Right  string `range:"6,10" align:"right"`
+		Raw    string `range:"10,14" trim:"none"`
+		Custom string `range:"14,18" trim:"both:-"`
+		Number int    `range:"18,22" fill:"*"`
+	}
+
+	var v rec
+	if err := Unmarshal([]byte("  ab    cd x  -ef-**42"), &v); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	want := rec{Text: "  ab", Right: "cd", Raw: " x  ", Custom: "ef", Number: 42}
+	if v != want {
+		t.Errorf("Expected %+v, got %+v", want, v)
+	}
+}
+
+func TestUnmarshalNumberFiller(t *testing.T) {
+	type rec struct {
+		_      struct{} `record:"prefix=0"`
+		Left   int      `range:"0,5" align:"left"`
+		Center float64  `range:"5,11" align:"center" decimals:"1"`
+		Right  int      `range:"11,15" align:"right"`
+	}
+
+	cfg := DefaultConfig()
+	cfg.Filler = '*'
+	codec := NewCodec(cfg)
+
+	in := rec{Left: 42, Center: 1.5, Right: 7}
+	res, err := codec.Marshal(&in)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(res) != "42*****15**0007" {
+		t.Fatalf("Unexpected record %q", res)
+	}
+
+	var out rec
+	if err := codec.Unmarshal(res, &out); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if out != in {
+		t.Errorf("Expected %+v, got %+v", in, out)
+	}
+}
+
+func TestUnmarshalNumberDigitFill(t *testing.T) {
+	type rec struct {
+		A int `range:"2,8" fill:"0"`
+		B int `range:"8,12" fill:"0" align:"right"`
+	}
+
+	in := rec{A: 100, B: 2000}
+	res, err := Marshal(&in)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(res) != "0001002000" {
+		t.Fatalf("Unexpected record %q", res)
+	}
+
+	var out rec
+	if err := Unmarshal(append([]byte("01"), res...), &out); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if out != in {
+		t.Errorf("Expected %+v, got %+v", in, out)
+	}
+}
+
+func TestUnmarshalNumberTrimSide(t *testing.T) {
+	type rec struct {
+		_ struct{} `record:"prefix=0"`
+		A int      `range:"0,6"`
+		B int      `range:"6,12" fill:"0"`
+	}
+
+	for _, align := range []AlignmentType{AlignmentTypeLeft, AlignmentTypeRight, AlignmentTypeCenter} {
+		for _, zeroes := range []bool{false, true} {
+			cfg := DefaultConfig()
+			cfg.AlignmentType = align
+			cfg.NumbersWithLeadingZeroes = zeroes
+			codec := NewCodec(cfg)
+
+			in := rec{A: 100, B: 1000}
+			res, err := codec.Marshal(&in)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			var out rec
+			if err := codec.Unmarshal(res, &out); err != nil {
+				t.Fatalf("Unmarshal %q failed: %v", res, err)
+			}
+			if out != in {
+				t.Errorf("alignment %v, leading zeroes %t: expected %+v from %q, got %+v", align, zeroes, in, res, out)
+			}
+		}
+	}
+}
+
+func TestUnmarshalSequentialLayout(t *testing.T) {
+	type rec struct {
+		Name   string `len:"4"`
+		Amount int    `len:"3"`
+		Code   string `pos:"10,11"`
+		Tail   string `len:"2"`
+	}
+
+	var v rec
+	if err := Unmarshal([]byte("01abcd042XYZW"), &v); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	want := rec{Name: "abcd", Amount: 42, Code: "XY", Tail: "ZW"}
+	if v != want {
+		t.Errorf("Expected %+v, got %+v", want, v)
+	}
+}
+
+func TestUnmarshalDefaults(t *testing.T) {
+	type rec struct {
+		Currency string `range:"0,3" default:"USD"`
+		Count    int    `range:"3,6" default:"1"`
+		Amount   int    `range:"6,10" flags:"omitzero"`
+		Note     string `range:"10,14"`
+	}
+
+	var v rec
+	if err := Unmarshal([]byte("              "), &v); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	want := rec{Currency: "USD", Count: 1}
+	if v != want {
+		t.Errorf("Expected %+v, got %+v", want, v)
+	}
+
+	if err := Unmarshal([]byte("EUR0070042note"), &v); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	want = rec{Currency: "EUR", Count: 7, Amount: 42, Note: "note"}
+	if v != want {
+		t.Errorf("Expected %+v, got %+v", want, v)
+	}
+}
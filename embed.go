@@ -0,0 +1,40 @@
+package fixedlength
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var ErrLayoutOverlap = errors.New("fixedlength: overlapping ranges")
+
+// layoutGroup holds the ranges of the own fields of a struct,
+// or of the fields promoted from one of its embedded structs.
+type layoutGroup struct {
+	path string
+	tags []tag
+}
+
+// isEmbeddedStructPointer reports whether sf is an embedded *T with T a struct.
+func isEmbeddedStructPointer(sf reflect.StructField) bool {
+	return sf.Anonymous && sf.Type.Kind() == reflect.Pointer && sf.Type.Elem().Kind() == reflect.Struct
+}
+
+// checkPromotedOverlaps reports promoted fields overlapping the own fields of the
+// embedding struct, or the fields promoted from another embedded struct.
+func checkPromotedOverlaps(own layoutGroup, promoted []layoutGroup) error {
+	groups := append([]layoutGroup{own}, promoted...)
+	for i, g := range promoted {
+		for _, other := range groups[:i+1] {
+			for _, a := range g.tags {
+				for _, b := range other.tags {
+					if a.fromPos < b.toPos && b.fromPos < a.toPos {
+						return fmt.Errorf("%w: %s.%s (%d,%d) and %s.%s (%d,%d)", ErrLayoutOverlap,
+							g.path, a.name, a.fromPos, a.toPos, other.path, b.name, b.fromPos, b.toPos)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
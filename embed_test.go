@@ -0,0 +1,70 @@
+package fixedlength
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type embedHeader struct {
+	Bank   string `range:"2,6"`
+	Branch int    `range:"6,9"`
+}
+
+type EmbedTrailer struct {
+	Checksum string `range:"15,17"`
+}
+
+func TestEmbeddedStructs(t *testing.T) {
+	type payment struct {
+		embedHeader
+		*EmbedTrailer
+		Amount int `range:"9,15"`
+	}
+
+	v := payment{embedHeader: embedHeader{Bank: "DEUT", Branch: 7}, EmbedTrailer: &EmbedTrailer{Checksum: "ZZ"}, Amount: 1500}
+	res, err := Marshal(&v)
+	require.NoError(t, err)
+	require.Equal(t, "DEUT007001500ZZ", string(res))
+
+	var got payment
+	require.NoError(t, Unmarshal(append([]byte("01"), res...), &got))
+	require.Equal(t, v.embedHeader, got.embedHeader)
+	require.NotNil(t, got.EmbedTrailer)
+	require.Equal(t, "ZZ", got.Checksum)
+	require.Equal(t, 1500, got.Amount)
+
+	res, err = Marshal(&payment{embedHeader: embedHeader{Bank: "DEUT", Branch: 7}, Amount: 1})
+	require.NoError(t, err)
+	require.Equal(t, "DEUT007000001  ", string(res))
+}
+
+func TestEmbeddedOverlap(t *testing.T) {
+	type payment struct {
+		embedHeader
+		Amount int `range:"8,12"`
+	}
+
+	_, err := Marshal(&payment{})
+	require.ErrorIs(t, err, ErrLayoutOverlap)
+
+	var v payment
+	err = Unmarshal([]byte("01DEUT0070015"), &v)
+	require.ErrorIs(t, err, ErrLayoutOverlap)
+	require.Contains(t, err.Error(), "payment.embedHeader.Branch (6,9) and payment.Amount (8,12)")
+}
+
+func TestEmbeddedSequentialLayout(t *testing.T) {
+	type base struct {
+		Kind string `len:"1"`
+		ID   string `len:"3"`
+	}
+	type rec struct {
+		base
+		Name string `len:"4"`
+	}
+
+	var v rec
+	require.NoError(t, Unmarshal([]byte("01A123abcd"), &v))
+	require.Equal(t, rec{base: base{Kind: "A", ID: "123"}, Name: "abcd"}, v)
+}
@@ -1,12 +1,13 @@
 package fixedlength
 
 import (
+	"encoding"
 	"errors"
 	"fmt"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
-	"unicode/utf8"
 )
 
 type Marshaler interface {
@@ -33,12 +34,33 @@ func implementsMarshaler(val reflect.Value) bool {
 	return false
 }
 
+// marshalerOf returns the Marshaler of a value of any kind, using the pointer
+// receiver when the value is addressable. Nil pointers have none.
+func marshalerOf(val reflect.Value) (Marshaler, bool) {
+	return interfaceOf[Marshaler](val, false)
+}
+
 // working with gaps:
 // field 1 [200,210)
 // field 2 [220, 230)
 // the gap between them : 10, starting from 210 (included ) and ended 219 (included)
 // 209|..........|220
+//
+// The output starts after the record type prefix (Config.TypePrefixLength characters).
+// A marker field tagged with `record:"length=<n>,prefix=<n>"` overrides the prefix
+// and pads the output up to the declared record length.
+// Zero values of fields flagged `flags:"omitzero"` are written blank.
+// The elements of arrays are written one after the other, scalar arrays take `len` tags.
+// time.Time fields tagged `format:"<layout>"` are written in that layout, zero times
+// are left blank.
+// Bool fields are written as "true" or "false", or "T" and "F" when the field is shorter
+// than 5 positions; Unmarshal parses both forms.
 func Marshal(d interface{}) ([]byte, error) {
+	return defaultCodec().Marshal(d)
+}
+
+// Marshal encodes d like the package level Marshal, using the codec configuration.
+func (c *Codec) Marshal(d interface{}) ([]byte, error) {
 	rv := reflect.ValueOf(d)
 	var structVal reflect.Value
 
@@ -49,86 +71,404 @@ func Marshal(d interface{}) ([]byte, error) {
 		}
 		structVal = rv.Elem()
 	case reflect.Struct:
-		structVal = rv
+		// copy to an addressable value, so pointer receiver marshalers are found
+		structVal = reflect.New(rv.Type()).Elem()
+		structVal.Set(rv)
 	default:
 		return nil, fmt.Errorf("invalid marshal value")
 	}
 
-	type tagWithFieldNumber struct {
-		tag      tag
-		fieldNum int
+	rec, err := parseStructRecordTag(structVal.Type())
+	if err != nil {
+		return nil, err
 	}
-	tagsWithPos := make([]tagWithFieldNumber, 0)
 
-	for i := 0; i < structVal.NumField(); i++ {
-		tag, err := parseFieldTag(structVal.Type().Field(i).Tag)
-		if err != nil {
-			if errors.Is(err, ErrTagEmpty) {
-				continue
-			}
-			if tag.flags.optional {
-				continue
-			}
-			return nil, fmt.Errorf("failed to parse tag %s (%s) : %w", structVal.Type().Field(i).Name, tag, err)
-		}
+	record, err := c.marshalStruct(structVal, structPath(structVal.Type()), c.recordStart(rec), rec.length)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.encodeRecord(record)
+}
 
-		tagsWithPos = append(tagsWithPos, tagWithFieldNumber{tag, i})
+// encodeRecord converts a record built by marshalStruct to the record charset.
+func (c *Codec) encodeRecord(record string) ([]byte, error) {
+	if c.config.PositionUnit == PositionUnitByte {
+		// fields are already in the record charset
+		return []byte(record), nil
 	}
 
-	sort.Slice(tagsWithPos, func(i, j int) bool {
-		return tagsWithPos[i].tag.fromPos < tagsWithPos[j].tag.fromPos
+	res, err := c.encodeText(c.config.Charset, record)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(res), nil
+}
+
+// marshalStruct writes the fields of sv from startPos on, in the codec position unit.
+// A non negative length pads the result with the codec filler up to that position.
+// In byte mode the result is already in the record charset.
+func (c *Codec) marshalStruct(sv reflect.Value, path string, startPos int, length int) (string, error) {
+	fields, _, err := c.collectFields(sv, path, startPos, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return c.writeFields(fields, startPos, length)
+}
+
+// writeFields marshals fields in position order from startPos on, filling the gaps.
+// A non negative length pads the result with the codec filler up to that position.
+func (c *Codec) writeFields(fields []fieldToMarshal, startPos int, length int) (string, error) {
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].tag.fromPos < fields[j].tag.fromPos
 	})
 
 	sb := strings.Builder{}
 	// use runes to handle utf-8
-	lastPos := 2 // we always start at 2 since the first two characters are the type and always filled outside
-	for _, tagWitPos := range tagsWithPos {
-
-		field := structVal.Field(tagWitPos.fieldNum)
-		str, err := MarshalField(field, tagWitPos.tag)
+	// the first characters are the record type and always filled outside
+	lastPos := startPos
+	for _, f := range fields {
+		str, err := c.marshalCollected(f)
 		if err != nil {
-			return nil, fmt.Errorf("failed to marshal field %s : %w", structVal.Type().Field(tagWitPos.fieldNum).Name, err)
+			return "", fmt.Errorf("failed to marshal field %s : %w", f.tag.name, err)
 		}
 
 		strStr := string(str)
-		strLen := utf8.RuneCountInString(strStr)
+		strLen := c.width(strStr)
 		// check if field is too long
-		tagLen := tagWitPos.tag.toPos - tagWitPos.tag.fromPos
+		tagLen := f.tag.toPos - f.tag.fromPos
 		if strLen > tagLen {
-			return nil, fmt.Errorf("field %s is too long, required: %d but %d", structVal.Type().Field(tagWitPos.fieldNum).Name, tagLen, strLen)
+			return "", fmt.Errorf("field %s is too long, required: %d but %d", f.tag.name, tagLen, strLen)
 		}
 
-		gap := tagWitPos.tag.fromPos - lastPos
+		gap := f.tag.fromPos - lastPos
 		if gap < 0 {
-			return nil, fmt.Errorf("field %s is overlapping with previous field", structVal.Type().Field(tagWitPos.fieldNum).Name)
+			return "", fmt.Errorf("field %s is overlapping with previous field", f.tag.name)
 		}
 
 		if gap > 0 {
-			sb.WriteString(fmt.Sprintf("%*s", gap, ""))
+			sb.WriteString(c.fillerString(gap))
 		}
 
 		// write the original string
 		sb.WriteString(strStr)
 
-		lastPos = tagWitPos.tag.toPos
+		lastPos = f.tag.toPos
 	}
-	return []byte(sb.String()), nil
+
+	if length >= 0 {
+		if lastPos > length {
+			return "", fmt.Errorf("record is longer than declared length %d: %d", length, lastPos)
+		}
+		if gap := length - lastPos; gap > 0 {
+			sb.WriteString(c.fillerString(gap))
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// fieldToMarshal is a tagged field found by collectFields.
+type fieldToMarshal struct {
+	tag   tag
+	value reflect.Value
+	path  string // struct path of variants, e.g. Record.Details
+
+	// encoded is the field data once marshaled, so marshalers and overflow callbacks
+	// run once for fields encoded ahead of writeFields
+	encoded   []byte
+	marshaled bool
+}
+
+// marshalCollected encodes a field found by collectFields.
+func (c *Codec) marshalCollected(f fieldToMarshal) ([]byte, error) {
+	if f.marshaled {
+		return f.encoded, nil
+	}
+	if f.tag.discriminator != "" {
+		str, err := c.marshalVariant(f.value, f.tag, f.path)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(str), nil
+	}
+	return c.marshalField(f.value, f.tag)
+}
+
+// collectFields runs the BeforeMarshal hook of sv and gathers its tagged fields,
+// walking into nested structs that don't encode themselves, like Unmarshal does.
+// Fields of embedded structs are promoted into the layout of sv, nil embedded pointers
+// are encoded as their zero value.
+// nextPos is where `len` fields start, the position after the last field is returned.
+func (c *Codec) collectFields(sv reflect.Value, path string, nextPos int, fields []fieldToMarshal) ([]fieldToMarshal, int, error) {
+	if err := runBeforeMarshal(sv, path); err != nil {
+		return nil, 0, err
+	}
+
+	own := layoutGroup{path: path}
+	var promoted []layoutGroup
+	var spans []viewSpan
+	layout := newRedefinesLayout(sv.Type())
+	for i := 0; i < sv.NumField(); i++ {
+		sf := sv.Type().Field(i)
+		if isRecordMarker(sf) {
+			continue
+		}
+
+		var err error
+		nextPos, err = layout.next(sf, nextPos)
+		if err != nil {
+			return nil, 0, err
+		}
+		before := len(fields)
+
+		field := sv.Field(i)
+		if isEmbeddedStructPointer(sf) {
+			if field.IsNil() {
+				field = reflect.New(sf.Type.Elem()).Elem()
+			} else {
+				field = field.Elem()
+			}
+		}
+
+		if field.Kind() == reflect.Struct && !c.encodesItself(field) && !isLiteralField(sf) {
+			fields, nextPos, err = c.collectFields(field, path+"."+sf.Name, nextPos, fields)
+			if err != nil {
+				return nil, 0, err
+			}
+			if sf.Anonymous {
+				group := layoutGroup{path: path + "." + sf.Name}
+				for _, f := range fields[before:] {
+					group.tags = append(group.tags, f.tag)
+				}
+				promoted = append(promoted, group)
+			}
+		} else if isArrayLayout(field) && !c.encodesItself(field) && !isLiteralField(sf) {
+			for k := 0; k < field.Len(); k++ {
+				elem := field.Index(k)
+				name := fmt.Sprintf("%s[%d]", sf.Name, k)
+				if elem.Kind() == reflect.Struct && !c.encodesItself(elem) {
+					fields, nextPos, err = c.collectFields(elem, path+"."+name, nextPos, fields)
+					if err != nil {
+						return nil, 0, err
+					}
+					continue
+				}
+
+				f, ok, err := c.collectField(sf, elem, nextPos)
+				if err != nil {
+					return nil, 0, err
+				}
+				if !ok {
+					break
+				}
+				f.tag.name = name
+				nextPos = f.tag.toPos
+				if !isView(sf) {
+					own.tags = append(own.tags, f.tag)
+				}
+				fields = append(fields, f)
+			}
+		} else {
+			f, ok, err := c.collectField(sf, field, nextPos)
+			if err != nil {
+				return nil, 0, err
+			}
+			if ok && f.tag.discriminator != "" {
+				f.path = path + "." + sf.Name
+				if err := checkVariant(sv, field, f.tag); err != nil {
+					return nil, 0, fmt.Errorf("invalid variant %s : %w", sf.Name, err)
+				}
+			}
+			if ok {
+				nextPos = f.tag.toPos
+				if !isView(sf) {
+					own.tags = append(own.tags, f.tag)
+				}
+				fields = append(fields, f)
+			}
+		}
+
+		if group, ok := layout.group(sf.Name); ok {
+			selected, err := viewSelected(sv, sf)
+			if err != nil {
+				return nil, 0, err
+			}
+			spans = append(spans, viewSpan{
+				group:       group,
+				name:        sf.Name,
+				from:        before,
+				to:          len(fields),
+				active:      selected && !field.IsZero(),
+				conditional: sf.Tag.Get("when") != "",
+			})
+		}
+	}
+	nextPos = layout.done(nextPos)
+
+	if err := checkPromotedOverlaps(own, promoted); err != nil {
+		return nil, 0, err
+	}
+
+	fields, err := c.selectViews(fields, spans, path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return fields, nextPos, nil
+}
+
+// collectField parses the tag of a field encoded as a whole, ok is false for untagged fields.
+func (c *Codec) collectField(sf reflect.StructField, field reflect.Value, nextPos int) (f fieldToMarshal, ok bool, err error) {
+	tag, err := parseFieldTagAt(sf.Tag, nextPos)
+	if err != nil {
+		if errors.Is(err, ErrTagEmpty) || tag.flags.optional {
+			return f, false, nil
+		}
+		return f, false, fmt.Errorf("failed to parse tag %s (%s) : %w", sf.Name, tag, err)
+	}
+	tag.name = sf.Name
+
+	if err := c.checkFieldTag(tag); err != nil {
+		return f, false, fmt.Errorf("invalid tag %s (%s) : %w", sf.Name, tag, err)
+	}
+
+	return fieldToMarshal{tag: tag, value: field}, true, nil
+}
+
+// encodesItself reports whether a value is encoded as a whole by a converter
+// or one of the marshaler interfaces, rather than field by field.
+func (c *Codec) encodesItself(val reflect.Value) bool {
+	if _, ok := c.converterFor(val.Type()); ok {
+		return true
+	}
+	return implementsMarshaler(val) || implementsInterface[FieldMarshaler](val) ||
+		implementsInterface[encoding.TextMarshaler](val)
+}
+
+// recordStart returns the first position after the record type prefix.
+func (c *Codec) recordStart(rec recordTag) int {
+	if rec.prefix >= 0 {
+		return rec.prefix
+	}
+	return c.config.TypePrefixLength
+}
+
+// fillerString returns n codec filler characters used for gaps and record padding.
+func (c *Codec) fillerString(n int) string {
+	return strings.Repeat(c.fillerUnit(c.config.Filler), n)
 }
 
 func MarshalField(field reflect.Value, t tag) ([]byte, error) {
+	return defaultCodec().marshalField(field, t)
+}
+
+func (c *Codec) marshalField(field reflect.Value, t tag) ([]byte, error) {
 	var str string
 	var err error
 
-	align := GetConfig().AlignmentType
+	if t.discriminator != "" {
+		str, err = c.marshalVariant(field, t, structPath(field.Type()))
+		if err != nil {
+			return nil, err
+		}
+		return []byte(str), nil
+	}
+
+	align := c.config.AlignmentType
 	if t.align != AlignmentTypeNone {
 		align = t.align
 	}
 
+	if t.constant != nil || t.flags.filler {
+		str, err = c.marshalLiteral(t, align)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(str), nil
+	}
+
+	if t.flags.omitzero && field.IsZero() {
+		return []byte(c.fillerString(t.Len())), nil
+	}
+
+	filler := c.config.Filler
+	if t.fill >= 0 {
+		filler = byte(t.fill)
+	}
+
+	if conv, ok := c.converterFor(field.Type()); ok && field.CanInterface() {
+		str, err = conv.encode(field, c.fieldInfo(t))
+		if err != nil {
+			return nil, err
+		}
+		str, err = c.format(str, t, align, filler)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(str), nil
+	}
+
+	if isFormattedTime(field, t) {
+		str, err = c.format(formatTime(field, t), t, align, filler)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(str), nil
+	}
+
+	// custom marshalers win over the kind, so named types like `type Cents int64` work
+	if fm, ok := interfaceOf[FieldMarshaler](field, false); ok {
+		ba, err := fm.MarshalFixedField(c.fieldInfo(t))
+		if err != nil {
+			return nil, err
+		}
+		str, err = c.format(string(ba), t, align, filler)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(str), nil
+	}
+
+	if m, ok := marshalerOf(field); ok {
+		ba, err := m.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		str, err = c.format(string(ba), t, align, filler)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(str), nil
+	}
+
+	// third party types often only speak encoding.TextMarshaler
+	if tm, ok := interfaceOf[encoding.TextMarshaler](field, false); ok {
+		ba, err := tm.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		str, err = c.format(string(ba), t, align, filler)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(str), nil
+	}
+
 	switch field.Kind() {
 	case reflect.String:
-		str = field.String()
+		str, err = c.fieldTransform(t).Apply(field.String())
+		if err != nil {
+			return nil, err
+		}
 
-		str, err = FormatStringWithAlignment(str, t.Len(), align)
+		str, err = c.fitField(t, str)
+		if err != nil {
+			return nil, err
+		}
+
+		str, err = c.format(str, t, align, filler)
 		if err != nil {
 			return nil, err
 		}
@@ -141,8 +481,7 @@ func MarshalField(field reflect.Value, t tag) ([]byte, error) {
 			return nil, fmt.Errorf("failed to convert int to EBCDIC: %w", err)
 		}
 
-		leadingZeroes := GetConfig().NumbersWithLeadingZeroes
-		str, err = FormatStrNumberWithAlignment(cVal, t.Len(), leadingZeroes, align)
+		str, err = c.formatNumber(cVal, t, align)
 		if err != nil {
 			return nil, err
 		}
@@ -152,24 +491,72 @@ func MarshalField(field reflect.Value, t tag) ([]byte, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert float to EBCDIC: %w", err)
 		}
-		leadingZeroes := GetConfig().NumbersWithLeadingZeroes
-		str, err = FormatStrNumberWithAlignment(cVal, t.Len(), leadingZeroes, align)
+		str, err = c.formatNumber(cVal, t, align)
 		if err != nil {
 			return nil, err
 		}
-	case reflect.Struct:
-		if implementsMarshaler(field) {
-			m := field.Interface().(Marshaler)
-			ba, err := m.Marshal()
-			if err != nil {
-				return nil, err
-			}
-			str, err = FormatStringWithAlignment(string(ba), t.Len(), align)
-			if err != nil {
-				return nil, err
-			}
+	case reflect.Bool:
+		str, err = c.format(formatBool(field.Bool(), t.Len()), t, align, filler)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		// nothing to write (e.g. nil pointers), keep the range blank
+		str, err = c.format("", t, align, filler)
+		if err != nil {
+			return nil, err
 		}
 	}
 
 	return []byte(str), nil
 }
+
+// formatBool returns b in a form strconv.ParseBool reads back that fits length positions.
+func formatBool(b bool, length int) string {
+	if length >= len("false") {
+		return strconv.FormatBool(b)
+	}
+	if b {
+		return "T"
+	}
+	return "F"
+}
+
+// formatNumber pads an already converted number. A fill tag replaces the
+// leading zeroes (or the codec filler) and right aligns unless told otherwise.
+// Leading zeroes only apply to right aligned numbers: an explicit left or center
+// alignment pads with the codec filler, since trailing zeroes would change the value.
+// Binary and packed numbers take the whole range, unpadded, like numbers with a
+// separate sign.
+func (c *Codec) formatNumber(strNum string, t tag, align AlignmentType) (string, error) {
+	if t.usage != UsageDisplay {
+		return encodeUsage(strNum, t)
+	}
+	strNum, err := encodeSign(strNum, t)
+	if err != nil {
+		return "", err
+	}
+	if t.sign.separate() {
+		// the sign and the zero filled digits already take the whole field
+		return c.format(strNum, t, AlignmentTypeRight, '0')
+	}
+
+	align, filler := c.numberPadding(t, align)
+	return c.format(strNum, t, align, filler)
+}
+
+// numberPadding returns the alignment and filler display numbers are padded with,
+// trimField trims the same filler.
+func (c *Codec) numberPadding(t tag, align AlignmentType) (AlignmentType, byte) {
+	if t.fill >= 0 {
+		if t.align == AlignmentTypeNone {
+			align = AlignmentTypeRight
+		}
+		return align, byte(t.fill)
+	}
+
+	if c.config.NumbersWithLeadingZeroes && (t.align == AlignmentTypeNone || t.align == AlignmentTypeRight) {
+		return AlignmentTypeRight, '0' // we always add zeroes in the beginning
+	}
+	return align, c.config.Filler
+}
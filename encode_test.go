@@ -2,6 +2,9 @@ package fixedlength
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -231,3 +234,180 @@ func TestMarshalFloats(t *testing.T) {
 		})
 	}
 }
+
+func TestMarshalRecordLength(t *testing.T) {
+	t.Run("pads to declared length", func(t *testing.T) {
+		type rec struct {
+			_      struct{} `record:"length=12"`
+			Field1 string   `range:"2,5"`
+		}
+
+		res, err := Marshal(&rec{Field1: "abc"})
+		require.NoError(t, err)
+		require.Equal(t, "abc       ", string(res))
+	})
+
+	t.Run("prefix override", func(t *testing.T) {
+		type rec struct {
+			_      struct{} `record:"length=8,prefix=0"`
+			Field1 string   `range:"0,3"`
+			Field2 int      `range:"3,5"`
+		}
+
+		res, err := Marshal(&rec{Field1: "ab", Field2: 7})
+		require.NoError(t, err)
+		require.Equal(t, "ab 07   ", string(res))
+	})
+
+	t.Run("fields exceed declared length", func(t *testing.T) {
+		type rec struct {
+			_      struct{} `record:"length=4"`
+			Field1 string   `range:"2,6"`
+		}
+
+		_, err := Marshal(&rec{Field1: "abcd"})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid record tag", func(t *testing.T) {
+		type rec struct {
+			_      struct{} `record:"length=x"`
+			Field1 string   `range:"2,6"`
+		}
+
+		_, err := Marshal(&rec{Field1: "abcd"})
+		require.Error(t, err)
+	})
+}
+
+func TestMarshalAlignment(t *testing.T) {
+	type rec struct {
+		Title  string `range:"2,9" align:"center"`
+		Left   int    `range:"9,14" align:"left"`
+		Center int    `range:"14,19" align:"center"`
+		Right  int    `range:"19,24" align:"right"`
+	}
+
+	res, err := Marshal(&rec{Title: "abc", Left: 42, Center: 7, Right: 42})
+	require.NoError(t, err)
+	require.Equal(t, "  abc  42     7  00042", string(res))
+
+	var v rec
+	require.NoError(t, Unmarshal(append([]byte("01"), res...), &v))
+	require.Equal(t, rec{Title: "abc", Left: 42, Center: 7, Right: 42}, v)
+}
+
+func TestMarshalSequentialLayout(t *testing.T) {
+	type rec struct {
+		Name   string `len:"4"`
+		Amount int    `len:"3"`
+		Code   string `pos:"11,12"`
+	}
+
+	res, err := Marshal(&rec{Name: "ab", Amount: 42, Code: "XY"})
+	require.NoError(t, err)
+	require.Equal(t, "ab  042 XY", string(res))
+}
+
+type accountNo string
+
+func (a accountNo) Marshal() ([]byte, error) {
+	return []byte("AC-" + string(a)), nil
+}
+
+type cents int64
+
+func (c *cents) Marshal() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d.%02d", int64(*c)/100, int64(*c)%100)), nil
+}
+
+func (c *cents) Unmarshal(data []byte) error {
+	units, frac, _ := strings.Cut(string(data), ".")
+	u, err := strconv.ParseInt(units, 10, 64)
+	if err != nil {
+		return err
+	}
+	f, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return err
+	}
+	*c = cents(u*100 + f)
+	return nil
+}
+
+func TestMarshalNamedTypes(t *testing.T) {
+	type rec struct {
+		Account accountNo `range:"2,10"`
+		Amount  cents     `range:"10,18" align:"right"`
+		Pointer *cents    `range:"18,24"`
+	}
+
+	p := cents(5)
+	v := rec{Account: "123", Amount: 12345, Pointer: &p}
+
+	res, err := Marshal(&v)
+	require.NoError(t, err)
+	require.Equal(t, "AC-123    123.450.05  ", string(res))
+
+	// pointer receivers are found on non-pointer values too
+	res, err = Marshal(v)
+	require.NoError(t, err)
+	require.Equal(t, "AC-123    123.450.05  ", string(res))
+
+	var got rec
+	require.NoError(t, Unmarshal(append([]byte("01"), res...), &got))
+	require.Equal(t, cents(12345), got.Amount)
+	require.Equal(t, cents(5), *got.Pointer)
+
+	res, err = Marshal(&rec{Account: "1"})
+	require.NoError(t, err)
+	require.Equal(t, "AC-1        0.00      ", string(res))
+}
+
+func TestMarshalOmitZero(t *testing.T) {
+	type rec struct {
+		Amount  int     `range:"2,12" flags:"omitzero"`
+		Count   int     `range:"12,15"`
+		Rate    float64 `range:"15,20" decimals:"2" flags:"omitzero"`
+		Pointer *cents  `range:"20,24" flags:"omitzero"`
+	}
+
+	res, err := Marshal(&rec{})
+	require.NoError(t, err)
+	require.Equal(t, "          000         ", string(res))
+
+	res, err = Marshal(&rec{Amount: 42, Count: 1, Rate: 1.5})
+	require.NoError(t, err)
+	require.Equal(t, "000000004200100150    ", string(res))
+
+	var got rec
+	require.NoError(t, Unmarshal([]byte("01          000         "), &got))
+	require.Equal(t, rec{}, got)
+}
+
+func TestMarshalBool(t *testing.T) {
+	type rec struct {
+		Active  bool `range:"2,7"`
+		Deleted bool `range:"7,12" align:"right"`
+	}
+
+	res, err := Marshal(&rec{Active: true})
+	require.NoError(t, err)
+	require.Equal(t, "true false", string(res))
+
+	var got rec
+	require.NoError(t, Unmarshal(append([]byte("01"), res...), &got))
+	require.Equal(t, rec{Active: true}, got)
+
+	type short struct {
+		Active  bool `range:"2,3"`
+		Deleted bool `range:"3,4"`
+	}
+	res, err = Marshal(&short{Active: true})
+	require.NoError(t, err)
+	require.Equal(t, "TF", string(res))
+
+	var gotShort short
+	require.NoError(t, Unmarshal(append([]byte("01"), res...), &gotShort))
+	require.Equal(t, short{Active: true}, gotShort)
+}
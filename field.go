@@ -0,0 +1,98 @@
+package fixedlength
+
+import (
+	"reflect"
+)
+
+// FieldInfo describes the field a custom type is marshaled into or unmarshaled from,
+// with the tag and codec settings already resolved.
+type FieldInfo struct {
+	Name         string
+	Length       int // in PositionUnit
+	Decimals     int // -1 means no decimals
+	Alignment    AlignmentType
+	Filler       byte
+	Charset      Charset // nil means UTF-8
+	PositionUnit PositionUnit
+}
+
+// FieldMarshaler is implemented by types that need the field settings to marshal themselves,
+// e.g. a money type serving every width and number of decimals.
+// It's checked before Marshaler. The result is padded like the output of a Marshaler.
+type FieldMarshaler interface {
+	MarshalFixedField(field FieldInfo) ([]byte, error)
+}
+
+// FieldUnmarshaler is the field aware counterpart of Unmarshaler, checked before it.
+// data is trimmed like the input of an Unmarshaler.
+type FieldUnmarshaler interface {
+	UnmarshalFixedField(field FieldInfo, data []byte) error
+}
+
+// fieldInfo resolves the settings of a field against the codec configuration.
+func (c *Codec) fieldInfo(t tag) FieldInfo {
+	align := c.config.AlignmentType
+	if t.align != AlignmentTypeNone {
+		align = t.align
+	}
+
+	filler := c.config.Filler
+	if t.fill >= 0 {
+		filler = byte(t.fill)
+	}
+
+	return FieldInfo{
+		Name:         t.name,
+		Length:       t.Len(),
+		Decimals:     t.decimals,
+		Alignment:    align,
+		Filler:       filler,
+		Charset:      c.fieldCharset(t),
+		PositionUnit: c.config.PositionUnit,
+	}
+}
+
+// interfaceOf returns val as the interface T. Addressable values are tried through
+// their pointer first, so pointer receivers are found. Nil pointers have no value
+// unless alloc is set and val is settable, then they are allocated.
+func interfaceOf[T any](val reflect.Value, alloc bool) (T, bool) {
+	var zero T
+	if !val.IsValid() || !val.CanInterface() {
+		return zero, false
+	}
+
+	it := reflect.TypeOf((*T)(nil)).Elem()
+	if val.Kind() != reflect.Pointer && val.CanAddr() && val.Addr().Type().Implements(it) {
+		return val.Addr().Interface().(T), true
+	}
+
+	if !val.Type().Implements(it) {
+		return zero, false
+	}
+
+	switch val.Kind() {
+	case reflect.Pointer:
+		if val.IsNil() {
+			if !alloc || !val.CanSet() {
+				return zero, false
+			}
+			val.Set(reflect.New(val.Type().Elem()))
+		}
+	case reflect.Interface:
+		if val.IsNil() {
+			return zero, false
+		}
+	}
+
+	return val.Interface().(T), true
+}
+
+// implementsInterface reports whether val or, when addressable, its pointer implements T.
+func implementsInterface[T any](val reflect.Value) bool {
+	if !val.IsValid() {
+		return false
+	}
+
+	it := reflect.TypeOf((*T)(nil)).Elem()
+	return val.Type().Implements(it) || (val.CanAddr() && val.Addr().Type().Implements(it))
+}
@@ -0,0 +1,96 @@
+package fixedlength
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// money formats itself with the decimals of the field it's in.
+type money struct {
+	units int64 // in 1/10000
+}
+
+func (m money) MarshalFixedField(f FieldInfo) ([]byte, error) {
+	decimals := max(f.Decimals, 0)
+	scale := int64(1)
+	for i := 0; i < 4-decimals; i++ {
+		scale *= 10
+	}
+	return []byte(fmt.Sprintf("%0*d", f.Length, m.units/scale)), nil
+}
+
+func (m *money) UnmarshalFixedField(f FieldInfo, data []byte) error {
+	v, err := strconv.ParseInt(strings.TrimLeft(string(data), "0"), 10, 64)
+	if err != nil && len(strings.TrimLeft(string(data), "0")) > 0 {
+		return err
+	}
+	for i := 0; i < 4-max(f.Decimals, 0); i++ {
+		v *= 10
+	}
+	m.units = v
+	return nil
+}
+
+func TestFieldMarshaler(t *testing.T) {
+	type rec struct {
+		Short money `range:"2,8" decimals:"2"`
+		Long  money `range:"8,20" decimals:"4"`
+	}
+
+	v := rec{Short: money{units: 1234567}, Long: money{units: 1234567}}
+	res, err := Marshal(&v)
+	require.NoError(t, err)
+	require.Equal(t, "012345000001234567", string(res))
+
+	var got rec
+	require.NoError(t, Unmarshal(append([]byte("01"), res...), &got))
+	require.Equal(t, money{units: 1234500}, got.Short)
+	require.Equal(t, money{units: 1234567}, got.Long)
+}
+
+func TestFieldInfo(t *testing.T) {
+	tag, err := parseFieldTag(`range:"0,8" decimals:"2" align:"right" fill:"*"`)
+	require.NoError(t, err)
+	tag.name = "Amount"
+
+	info := NewCodec(DefaultConfig()).fieldInfo(tag)
+	require.Equal(t, FieldInfo{
+		Name:      "Amount",
+		Length:    8,
+		Decimals:  2,
+		Alignment: AlignmentTypeRight,
+		Filler:    '*',
+	}, info)
+}
+
+// bothMarshalers checks the package interfaces win over encoding.TextMarshaler.
+type bothMarshalers string
+
+func (b bothMarshalers) Marshal() ([]byte, error)     { return []byte("pkg"), nil }
+func (b bothMarshalers) MarshalText() ([]byte, error) { return []byte("text"), nil }
+
+func TestTextMarshalers(t *testing.T) {
+	type rec struct {
+		IP   net.IP         `range:"2,17"`
+		Date time.Time      `range:"17,37"`
+		Both bothMarshalers `range:"37,41"`
+	}
+
+	date := time.Date(2024, 5, 17, 10, 30, 0, 0, time.UTC)
+	v := rec{IP: net.ParseIP("192.168.10.1"), Date: date}
+
+	res, err := Marshal(&v)
+	require.NoError(t, err)
+	require.Equal(t, "192.168.10.1   2024-05-17T10:30:00Zpkg ", string(res))
+
+	var got rec
+	require.NoError(t, Unmarshal(append([]byte("01"), res...), &got))
+	require.True(t, v.IP.Equal(got.IP))
+	require.True(t, date.Equal(got.Date))
+}
@@ -0,0 +1,59 @@
+package fixedlength
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// BeforeMarshaler is implemented by structs that prepare themselves before Marshal
+// encodes them, e.g. to compute record totals. Nested structs are called after their parent.
+type BeforeMarshaler interface {
+	BeforeMarshal() error
+}
+
+// AfterUnmarshaler is implemented by structs that check themselves once Unmarshal has
+// decoded all their fields. Nested structs are called before their parent.
+type AfterUnmarshaler interface {
+	AfterUnmarshal() error
+}
+
+// HookError wraps an error returned by a BeforeMarshal or AfterUnmarshal hook.
+type HookError struct {
+	Hook string
+	Path string // struct path, e.g. Record.Header
+	Err  error
+}
+
+func (e HookError) Error() string {
+	return fmt.Sprintf("fixedlength: %s %s: %v", e.Hook, e.Path, e.Err)
+}
+
+func (e HookError) Unwrap() error {
+	return e.Err
+}
+
+func runBeforeMarshal(sv reflect.Value, path string) error {
+	if h, ok := interfaceOf[BeforeMarshaler](sv, false); ok {
+		if err := h.BeforeMarshal(); err != nil {
+			return HookError{Hook: "BeforeMarshal", Path: path, Err: err}
+		}
+	}
+	return nil
+}
+
+func runAfterUnmarshal(sv reflect.Value, path string) error {
+	if h, ok := interfaceOf[AfterUnmarshaler](sv, false); ok {
+		if err := h.AfterUnmarshal(); err != nil {
+			return HookError{Hook: "AfterUnmarshal", Path: path, Err: err}
+		}
+	}
+	return nil
+}
+
+// structPath names the root of a struct path.
+func structPath(t reflect.Type) string {
+	if t.Name() != "" {
+		return t.Name()
+	}
+	return t.String()
+}
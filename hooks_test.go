@@ -0,0 +1,65 @@
+package fixedlength
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type hookHeader struct {
+	Name string `range:"2,8"`
+}
+
+func (h *hookHeader) BeforeMarshal() error {
+	h.Name = strings.ToUpper(h.Name)
+	return nil
+}
+
+func (h *hookHeader) AfterUnmarshal() error {
+	if h.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+type hookRecord struct {
+	Header hookHeader
+	A      int `range:"8,11"`
+	B      int `range:"11,14"`
+	Total  int `range:"14,18"`
+}
+
+func (r *hookRecord) BeforeMarshal() error {
+	r.Total = r.A + r.B
+	return nil
+}
+
+func (r *hookRecord) AfterUnmarshal() error {
+	if r.Total != r.A+r.B {
+		return errors.New("total mismatch")
+	}
+	return nil
+}
+
+func TestHooks(t *testing.T) {
+	res, err := Marshal(&hookRecord{Header: hookHeader{Name: "acme"}, A: 12, B: 30})
+	require.NoError(t, err)
+	require.Equal(t, "ACME  0120300042", string(res))
+
+	var v hookRecord
+	require.NoError(t, Unmarshal(append([]byte("01"), res...), &v))
+	require.Equal(t, hookRecord{Header: hookHeader{Name: "ACME"}, A: 12, B: 30, Total: 42}, v)
+
+	err = Unmarshal([]byte("01ACME  0120300040"), &v)
+	var hookErr HookError
+	require.ErrorAs(t, err, &hookErr)
+	require.Equal(t, "AfterUnmarshal", hookErr.Hook)
+	require.Equal(t, "hookRecord", hookErr.Path)
+
+	err = Unmarshal([]byte("01      0120300042"), &v)
+	require.ErrorAs(t, err, &hookErr)
+	require.Equal(t, "hookRecord.Header", hookErr.Path)
+	require.EqualError(t, err, "fixedlength: AfterUnmarshal hookRecord.Header: name is required")
+}
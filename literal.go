@@ -0,0 +1,75 @@
+package fixedlength
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrConstantMismatch = errors.New("fixedlength: constant mismatch")
+	ErrFillerNotBlank   = errors.New("fixedlength: filler is not blank")
+)
+
+// isLiteralField reports whether sf is a constant, tagged with `const:"<literal>"`,
+// or a FILLER range, flagged `flags:"filler"`. Literal fields are written by Marshal
+// whatever their value, so they are usually declared as `_ struct{}`.
+func isLiteralField(sf reflect.StructField) bool {
+	if _, ok := sf.Tag.Lookup("const"); ok {
+		return true
+	}
+	f, _ := parseFlagsTag(sf.Tag.Get("flags"))
+	return f.filler
+}
+
+// holdsValue reports whether a literal field has room for the decoded value.
+func holdsValue(field reflect.Value) bool {
+	if !field.CanSet() {
+		return false
+	}
+	return field.Kind() != reflect.Struct || field.NumField() > 0
+}
+
+// literalFiller returns the character filling the range of a FILLER field.
+func (c *Codec) literalFiller(t tag) byte {
+	if t.fill >= 0 {
+		return byte(t.fill)
+	}
+	return c.config.Filler
+}
+
+// marshalLiteral writes the constant of t, or fills its range.
+func (c *Codec) marshalLiteral(t tag, align AlignmentType) (string, error) {
+	if t.constant != nil {
+		return c.format(*t.constant, t, align, c.config.Filler)
+	}
+	return strings.Repeat(c.fillerUnit(c.literalFiller(t)), t.Len()), nil
+}
+
+// checkLiteral verifies the raw text of a literal field: constants must match,
+// padding aside, FILLER ranges must be blank when the codec is strict.
+func (c *Codec) checkLiteral(raw string, t tag) error {
+	if t.constant != nil {
+		want := TrimString(*t.constant, TrimTypeBoth, trimCutset(c.config.Filler))
+		if got := c.trimFiller(raw, TrimTypeBoth, t, c.config.Filler); got != want {
+			return fmt.Errorf("%w: want %q, got %q", ErrConstantMismatch, want, got)
+		}
+		return nil
+	}
+
+	if c.config.Strict {
+		if rest := c.trimFiller(raw, TrimTypeBoth, t, c.literalFiller(t)); rest != "" {
+			return fmt.Errorf("%w: %q", ErrFillerNotBlank, raw)
+		}
+	}
+	return nil
+}
+
+// trimCutset returns the TrimString cutset of a filler, white space for a space.
+func trimCutset(filler byte) string {
+	if filler == ' ' {
+		return ""
+	}
+	return string(rune(filler))
+}
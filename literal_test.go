@@ -0,0 +1,59 @@
+package fixedlength
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type literalRecord struct {
+	_       struct{} `record:"prefix=0"`
+	_       struct{} `range:"0,1" const:"6"`
+	Version string   `range:"1,4" const:"V2"`
+	Name    string   `range:"4,10"`
+	_       struct{} `range:"10,14" flags:"filler"`
+	Amount  int      `range:"14,18"`
+	_       struct{} `range:"18,20" flags:"filler" fill:"*"`
+}
+
+func TestLiteralMarshal(t *testing.T) {
+	res, err := Marshal(&literalRecord{Name: "ACME", Amount: 42})
+	require.NoError(t, err)
+	require.Equal(t, "6V2 ACME      0042**", string(res))
+
+	// constants win over the field value
+	res, err = Marshal(&literalRecord{Version: "V1"})
+	require.NoError(t, err)
+	require.Equal(t, "6V2           0000**", string(res))
+}
+
+func TestLiteralUnmarshal(t *testing.T) {
+	var got literalRecord
+	require.NoError(t, Unmarshal([]byte("6V2 ACME      0042**"), &got))
+	require.Equal(t, literalRecord{Version: "V2", Name: "ACME", Amount: 42}, got)
+
+	err := Unmarshal([]byte("7V2 ACME      0042**"), &got)
+	require.ErrorIs(t, err, ErrConstantMismatch)
+
+	err = Unmarshal([]byte("6V3 ACME      0042**"), &got)
+	require.ErrorIs(t, err, ErrConstantMismatch)
+	require.Contains(t, err.Error(), `want "V2", got "V3"`)
+
+	// filler ranges are only validated by strict codecs
+	require.NoError(t, Unmarshal([]byte("6V2 ACME  XX  0042**"), &got))
+}
+
+func TestLiteralStrictFiller(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Strict = true
+	c := NewCodec(cfg)
+
+	var got literalRecord
+	require.NoError(t, c.Unmarshal([]byte("6V2 ACME      0042**"), &got))
+
+	err := c.Unmarshal([]byte("6V2 ACME  XX  0042**"), &got)
+	require.ErrorIs(t, err, ErrFillerNotBlank)
+
+	err = c.Unmarshal([]byte("6V2 ACME      0042  "), &got)
+	require.ErrorIs(t, err, ErrFillerNotBlank)
+}
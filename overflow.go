@@ -0,0 +1,91 @@
+package fixedlength
+
+import (
+	"fmt"
+)
+
+// OverflowType decides what Marshal does with strings longer than their field.
+type OverflowType int
+
+var (
+	OverflowTypeError         OverflowType = 0
+	OverflowTypeTruncateRight OverflowType = 1 // keep the beginning
+	OverflowTypeTruncateLeft  OverflowType = 2 // keep the end
+	OverflowTypeEllipsis      OverflowType = 3 // keep the beginning and end it with "..."
+)
+
+const ellipsis = "..."
+
+// TruncateFunc is called whenever a field value is truncated to fit its range.
+type TruncateFunc func(field string, original string, truncated string)
+
+func parseOverflowTag(tag string) (OverflowType, bool, error) {
+	switch tag {
+	case "":
+		return OverflowTypeError, false, nil
+	case "error":
+		return OverflowTypeError, true, nil
+	case "truncate", "truncate-right":
+		return OverflowTypeTruncateRight, true, nil
+	case "truncate-left":
+		return OverflowTypeTruncateLeft, true, nil
+	case "ellipsis":
+		return OverflowTypeEllipsis, true, nil
+	}
+	return OverflowTypeError, false, fmt.Errorf("invalid overflow type: %s", tag)
+}
+
+// fieldWidth measures the text of a field in the codec position unit,
+// in byte mode once it's encoded in the field charset.
+func (c *Codec) fieldWidth(t tag, str string) (int, error) {
+	if c.config.PositionUnit == PositionUnitByte {
+		encoded, err := c.encodeText(c.fieldCharset(t), str)
+		return len(encoded), err
+	}
+	return c.width(str), nil
+}
+
+// fitField applies the overflow policy of the field to str.
+func (c *Codec) fitField(t tag, str string) (string, error) {
+	overflow := c.config.Overflow
+	if t.hasOverflow {
+		overflow = t.overflow
+	}
+	if overflow == OverflowTypeError {
+		return str, nil // the length check reports it
+	}
+
+	w, err := c.fieldWidth(t, str)
+	if err != nil || w <= t.Len() {
+		return str, err
+	}
+
+	runes := []rune(str)
+	suffix := ""
+	if overflow == OverflowTypeEllipsis && t.Len() > len(ellipsis) {
+		suffix = ellipsis
+	}
+
+	// drop whole characters until the rest fits
+	res := ""
+	for n := len(runes) - 1; n >= 0; n-- {
+		if overflow == OverflowTypeTruncateLeft {
+			res = string(runes[len(runes)-n:])
+		} else {
+			res = string(runes[:n]) + suffix
+		}
+
+		w, err := c.fieldWidth(t, res)
+		if err != nil {
+			return "", err
+		}
+		if w <= t.Len() {
+			break
+		}
+	}
+
+	if c.config.OnTruncate != nil {
+		c.config.OnTruncate(t.name, str, res)
+	}
+	return res, nil
+}
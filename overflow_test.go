@@ -0,0 +1,74 @@
+package fixedlength
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalOverflow(t *testing.T) {
+	type rec struct {
+		Right    string `range:"0,5" overflow:"truncate"`
+		Left     string `range:"5,10" overflow:"truncate-left"`
+		Ellipsis string `range:"10,17" overflow:"ellipsis"`
+		Strict   string `range:"17,20"`
+	}
+
+	var truncated []string
+	cfg := DefaultConfig()
+	cfg.TypePrefixLength = 0
+	cfg.OnTruncate = func(field string, original string, res string) {
+		truncated = append(truncated, field+":"+original+">"+res)
+	}
+	c := NewCodec(cfg)
+
+	res, err := c.Marshal(&rec{Right: "Hauptstraße", Left: "0123456789", Ellipsis: "Long street name", Strict: "ab"})
+	require.NoError(t, err)
+	require.Equal(t, "Haupt56789Long...ab ", string(res))
+	require.Equal(t, []string{
+		"Right:Hauptstraße>Haupt",
+		"Left:0123456789>56789",
+		"Ellipsis:Long street name>Long...",
+	}, truncated)
+
+	_, err = c.Marshal(&rec{Strict: "abcd"})
+	require.Error(t, err)
+
+	t.Run("codec default", func(t *testing.T) {
+		type rec struct {
+			Name string `range:"0,3"`
+		}
+		cfg := DefaultConfig()
+		cfg.TypePrefixLength = 0
+		cfg.Overflow = OverflowTypeTruncateRight
+		res, err := NewCodec(cfg).Marshal(&rec{Name: "abcdef"})
+		require.NoError(t, err)
+		require.Equal(t, "abc", string(res))
+	})
+
+	t.Run("byte positions keep characters whole", func(t *testing.T) {
+		type rec struct {
+			Name string `range:"0,4" overflow:"truncate"`
+		}
+		cfg := DefaultConfig()
+		cfg.TypePrefixLength = 0
+		cfg.PositionUnit = PositionUnitByte
+		res, err := NewCodec(cfg).Marshal(&rec{Name: "aüüü"})
+		require.NoError(t, err)
+		require.Equal(t, "aü ", string(res))
+	})
+}
+
+func TestParseOverflowTag(t *testing.T) {
+	overflow, ok, err := parseOverflowTag("ellipsis")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, OverflowTypeEllipsis, overflow)
+
+	_, ok, err = parseOverflowTag("")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, _, err = parseOverflowTag("wrap")
+	require.Error(t, err)
+}
@@ -0,0 +1,124 @@
+package fixedlength
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var ErrSplitCharacter = errors.New("fixedlength: field boundary inside a wide character")
+
+// recordReader slices fields out of record data in the codec position unit.
+type recordReader struct {
+	c      *Codec
+	data   []byte // byte positions
+	runes  []rune // rune and column positions
+	starts []int  // column positions: the column where each rune starts
+	length int
+}
+
+func (c *Codec) newRecordReader(data []byte) (*recordReader, error) {
+	r := &recordReader{c: c, data: data, length: len(data)}
+	if c.config.PositionUnit == PositionUnitByte {
+		return r, nil
+	}
+
+	// convert to runes since we use utf-8 here
+	text, err := decodeText(c.config.Charset, data)
+	if err != nil {
+		return nil, err
+	}
+	r.runes = []rune(text)
+	r.length = len(r.runes)
+
+	if c.config.PositionUnit == PositionUnitColumn {
+		r.starts = make([]int, len(r.runes))
+		col := 0
+		for i, ru := range r.runes {
+			w := RuneDisplayWidth(ru)
+			if w == 0 && i > 0 {
+				// combining marks stay with the character they follow
+				r.starts[i] = r.starts[i-1]
+				continue
+			}
+			r.starts[i] = col
+			col += w
+		}
+		r.length = col
+	}
+
+	return r, nil
+}
+
+// Len returns the record length in position units.
+func (r *recordReader) Len() int {
+	return r.length
+}
+
+// field returns the UTF-8 text of the range of t, which must be validated against Len.
+func (r *recordReader) field(t tag) (string, error) {
+	switch r.c.config.PositionUnit {
+	case PositionUnitByte:
+		return decodeText(r.c.fieldCharset(t), r.data[t.fromPos:t.toPos])
+	case PositionUnitColumn:
+		from, to, err := r.columns(t)
+		if err != nil {
+			return "", err
+		}
+		return string(r.runes[from:to]), nil
+	}
+	return string(r.runes[t.fromPos:t.toPos]), nil
+}
+
+// columns returns the runes in the column range of t. Ranges starting or ending in
+// the second column of a full width character are an error.
+func (r *recordReader) columns(t tag) (int, int, error) {
+	from, err := r.columnIndex(t.fromPos)
+	if err != nil {
+		return 0, 0, err
+	}
+	to, err := r.columnIndex(t.toPos)
+	if err != nil {
+		return 0, 0, err
+	}
+	return from, to, nil
+}
+
+// columnIndex returns the index of the rune starting at column pos, the rune count
+// at the end of the record.
+func (r *recordReader) columnIndex(pos int) (int, error) {
+	i := sort.SearchInts(r.starts, pos)
+	if i < len(r.starts) && r.starts[i] != pos || i == len(r.starts) && pos != r.length {
+		return 0, fmt.Errorf("%w: column %d", ErrSplitCharacter, pos)
+	}
+	return i, nil
+}
+
+// raw returns the bytes of the range of t, which must be validated against Len.
+// Only byte positions keep the record bytes.
+func (r *recordReader) raw(t tag) []byte {
+	return r.data[t.fromPos:t.toPos]
+}
+
+// window returns a reader over the range of t, validated against Len,
+// where positions start at 0 again.
+func (r *recordReader) window(t tag) (*recordReader, error) {
+	w := &recordReader{c: r.c, length: t.Len()}
+	switch r.c.config.PositionUnit {
+	case PositionUnitByte:
+		w.data = r.data[t.fromPos:t.toPos]
+	case PositionUnitColumn:
+		from, to, err := r.columns(t)
+		if err != nil {
+			return nil, err
+		}
+		w.runes = r.runes[from:to]
+		w.starts = make([]int, to-from)
+		for i, start := range r.starts[from:to] {
+			w.starts[i] = start - t.fromPos
+		}
+	default:
+		w.runes = r.runes[t.fromPos:t.toPos]
+	}
+	return w, nil
+}
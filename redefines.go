@@ -0,0 +1,277 @@
+package fixedlength
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrInvalidRedefines  = errors.New("fixedlength: invalid redefines")
+	ErrRedefinesConflict = errors.New("fixedlength: more than one active view")
+)
+
+// redefinesLayout tracks where the fields of one struct start, so views declared with
+// `redefines:"<field>"` start where the redefined field starts, like a COBOL REDEFINES,
+// and the fields after a view continue where the redefined field ends.
+// Views are exempt from the overlap checks.
+type redefinesLayout struct {
+	starts    map[string]int
+	ends      map[string]int
+	roots     map[string]string // view to the first field of its group
+	redefined map[string]bool
+	prev      string
+}
+
+func newRedefinesLayout(st reflect.Type) *redefinesLayout {
+	l := &redefinesLayout{
+		starts:    map[string]int{},
+		ends:      map[string]int{},
+		roots:     map[string]string{},
+		redefined: map[string]bool{},
+	}
+	for i := 0; i < st.NumField(); i++ {
+		if base := st.Field(i).Tag.Get("redefines"); base != "" {
+			l.redefined[base] = true
+		}
+	}
+	return l
+}
+
+// next returns where sf starts, nextPos is where the previous field ended.
+func (l *redefinesLayout) next(sf reflect.StructField, nextPos int) (int, error) {
+	nextPos = l.done(nextPos)
+	l.prev = sf.Name
+
+	base := sf.Tag.Get("redefines")
+	if base == "" {
+		l.starts[sf.Name] = nextPos
+		return nextPos, nil
+	}
+
+	start, ok := l.starts[base]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s redefines %s, which must be declared before it", ErrInvalidRedefines, sf.Name, base)
+	}
+	root := base
+	if r, ok := l.roots[base]; ok {
+		root = r
+	}
+	l.roots[sf.Name] = root
+	l.starts[sf.Name] = start
+	return start, nil
+}
+
+// done returns where the field after the previous one starts, nextPos is where
+// the previous field ended.
+func (l *redefinesLayout) done(nextPos int) int {
+	prev := l.prev
+	l.prev = ""
+	if prev == "" {
+		return nextPos
+	}
+	if root, ok := l.roots[prev]; ok {
+		return l.ends[root]
+	}
+	l.ends[prev] = nextPos
+	return nextPos
+}
+
+// group returns the first field of the redefinition group of name, if it has one.
+func (l *redefinesLayout) group(name string) (string, bool) {
+	if root, ok := l.roots[name]; ok {
+		return root, true
+	}
+	return name, l.redefined[name]
+}
+
+// isView reports whether sf redefines another field.
+func isView(sf reflect.StructField) bool {
+	return sf.Tag.Get("redefines") != ""
+}
+
+// viewSelected reports whether the `when:"<field>=<code>[|<code>...]"` condition of sf
+// holds for the fields of sv, which must be declared before it. Fields without a
+// condition are always selected.
+func viewSelected(sv reflect.Value, sf reflect.StructField) (bool, error) {
+	when := sf.Tag.Get("when")
+	if when == "" {
+		return true, nil
+	}
+
+	name, codes, ok := strings.Cut(when, "=")
+	if !ok || strings.TrimSpace(name) == "" {
+		return false, fmt.Errorf("%w: invalid when tag of %s: %q", ErrInvalidRedefines, sf.Name, when)
+	}
+	code, err := fieldCode(sv, strings.TrimSpace(name))
+	if err != nil {
+		return false, fmt.Errorf("%w: when tag of %s: %w", ErrInvalidRedefines, sf.Name, err)
+	}
+	for _, c := range strings.Split(codes, "|") {
+		if strings.TrimSpace(c) == code {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// viewSpan is the range of the collected fields of one member of a redefinition group.
+type viewSpan struct {
+	group       string
+	name        string
+	from        int
+	to          int
+	active      bool // not zero and the condition, if any, holds
+	conditional bool
+}
+
+// selectViews drops the collected fields of the inactive members of each redefinition
+// group. Members are active when they are not zero and their `when` condition holds,
+// active members with a condition win over the ones without. Active members encoding to
+// the same data, like the views of a decoded record, don't conflict, so decoded records
+// encode back; otherwise more than one active member is an error. The first member is
+// written when none is active.
+func (c *Codec) selectViews(fields []fieldToMarshal, spans []viewSpan, path string) ([]fieldToMarshal, error) {
+	if len(spans) == 0 {
+		return fields, nil
+	}
+
+	active := map[string]int{}
+	for _, conditional := range []bool{true, false} {
+		var groups []string
+		found := map[string][]int{}
+		for i, s := range spans {
+			if !s.active || s.conditional != conditional {
+				continue
+			}
+			if _, ok := active[s.group]; ok {
+				continue
+			}
+			if _, ok := found[s.group]; !ok {
+				groups = append(groups, s.group)
+			}
+			found[s.group] = append(found[s.group], i)
+		}
+		for _, group := range groups {
+			i, err := c.agreeingView(fields, spans, found[group], path)
+			if err != nil {
+				return nil, err
+			}
+			active[group] = i
+		}
+	}
+
+	drop := make([]bool, len(fields))
+	for i, s := range spans {
+		j, ok := active[s.group]
+		if ok && i == j || !ok && s.name == s.group {
+			continue
+		}
+		for k := s.from; k < s.to; k++ {
+			drop[k] = true
+		}
+	}
+
+	res := fields[:0]
+	for i, f := range fields {
+		if !drop[i] {
+			res = append(res, f)
+		}
+	}
+	return res, nil
+}
+
+// agreeingView returns which of the active members of a redefinition group to write.
+// Members agree when the data of the shorter ones starts the data of the longer ones,
+// the longest is written then. Their fields keep the marshaled data, so the written
+// member isn't marshaled again.
+func (c *Codec) agreeingView(fields []fieldToMarshal, spans []viewSpan, members []int, path string) (int, error) {
+	if len(members) == 1 {
+		return members[0], nil
+	}
+
+	start := -1
+	for _, i := range members {
+		for _, f := range fields[spans[i].from:spans[i].to] {
+			if start < 0 || f.tag.fromPos < start {
+				start = f.tag.fromPos
+			}
+		}
+	}
+
+	best, bestData := -1, ""
+	for _, i := range members {
+		for k := spans[i].from; k < spans[i].to; k++ {
+			f := &fields[k]
+			data, err := c.marshalCollected(*f)
+			if err != nil {
+				return 0, fmt.Errorf("failed to marshal field %s : %w", f.tag.name, err)
+			}
+			f.encoded, f.marshaled = data, true
+		}
+
+		own := append([]fieldToMarshal(nil), fields[spans[i].from:spans[i].to]...)
+		data, err := c.writeFields(own, start, -1)
+		if err != nil {
+			return 0, err
+		}
+
+		switch {
+		case best < 0:
+			best, bestData = i, data
+		case strings.HasPrefix(bestData, data):
+		case strings.HasPrefix(data, bestData):
+			best, bestData = i, data
+		default:
+			return 0, fmt.Errorf("%w: %s.%s and %s.%s", ErrRedefinesConflict, path, spans[best].name, path, spans[i].name)
+		}
+	}
+	return best, nil
+}
+
+// skipField returns where sf ends when laid out from nextPos, without decoding it.
+func (c *Codec) skipField(sf reflect.StructField, nextPos int) (int, error) {
+	ft := sf.Type
+	if isEmbeddedStructPointer(sf) {
+		ft = ft.Elem()
+	}
+
+	v := reflect.New(ft).Elem()
+	if isArrayLayout(v) && !c.decodesItself(v) && !isLiteralField(sf) {
+		elem := sf
+		elem.Type = ft.Elem()
+		for k := 0; k < ft.Len(); k++ {
+			var err error
+			if nextPos, err = c.skipField(elem, nextPos); err != nil {
+				return 0, err
+			}
+		}
+		return nextPos, nil
+	}
+	if v.Kind() == reflect.Struct && !c.decodesItself(v) && !isLiteralField(sf) {
+		layout := newRedefinesLayout(ft)
+		for i := 0; i < ft.NumField(); i++ {
+			nested := ft.Field(i)
+			if isRecordMarker(nested) {
+				continue
+			}
+			start, err := layout.next(nested, nextPos)
+			if err != nil {
+				return 0, err
+			}
+			nextPos, err = c.skipField(nested, start)
+			if err != nil {
+				return 0, err
+			}
+		}
+		return layout.done(nextPos), nil
+	}
+
+	// untagged and invalid fields are reported when the field is decoded
+	tag, err := parseFieldTagAt(sf.Tag, nextPos)
+	if err != nil {
+		return nextPos, nil
+	}
+	return tag.toPos, nil
+}
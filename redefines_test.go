@@ -0,0 +1,122 @@
+package fixedlength
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type redefinesAmount struct {
+	Units int `len:"6"`
+	Cents int `len:"2"`
+}
+
+type redefinesRecord struct {
+	Kind   string          `range:"2,3"`
+	Raw    string          `len:"8"`
+	Amount redefinesAmount `redefines:"Raw"`
+	Code   string          `len:"4" redefines:"Raw"`
+	Tail   string          `len:"2"`
+}
+
+func TestRedefinesDecodesAllViews(t *testing.T) {
+	var got redefinesRecord
+	require.NoError(t, Unmarshal([]byte("01A00012345XY"), &got))
+	require.Equal(t, redefinesRecord{
+		Kind:   "A",
+		Raw:    "00012345",
+		Amount: redefinesAmount{Units: 123, Cents: 45},
+		Code:   "0001",
+		Tail:   "XY",
+	}, got)
+}
+
+func TestRedefinesEncodesActiveView(t *testing.T) {
+	res, err := Marshal(&redefinesRecord{Kind: "A", Amount: redefinesAmount{Units: 123, Cents: 45}, Tail: "XY"})
+	require.NoError(t, err)
+	require.Equal(t, "A00012345XY", string(res))
+
+	res, err = Marshal(&redefinesRecord{Kind: "B", Code: "AB", Tail: "XY"})
+	require.NoError(t, err)
+	require.Equal(t, "BAB      XY", string(res))
+
+	res, err = Marshal(&redefinesRecord{Kind: "C"})
+	require.NoError(t, err)
+	require.Equal(t, "C          ", string(res))
+
+	_, err = Marshal(&redefinesRecord{Raw: "RAW", Code: "AB"})
+	require.ErrorIs(t, err, ErrRedefinesConflict)
+	require.Contains(t, err.Error(), "redefinesRecord.Raw and redefinesRecord.Code")
+}
+
+func TestRedefinesSelectedView(t *testing.T) {
+	type record struct {
+		Kind   string          `range:"2,3"`
+		Raw    string          `len:"8"`
+		Amount redefinesAmount `redefines:"Raw" when:"Kind=A|M"`
+		Text   string          `len:"8" redefines:"Raw" when:"Kind=T"`
+		Tail   string          `len:"2"`
+	}
+
+	var got record
+	require.NoError(t, Unmarshal([]byte("01THELLO   XY"), &got))
+	require.Equal(t, record{Kind: "T", Raw: "HELLO", Text: "HELLO", Tail: "XY"}, got)
+
+	res, err := Marshal(&got)
+	require.NoError(t, err)
+	require.Equal(t, "THELLO   XY", string(res))
+
+	got = record{}
+	require.NoError(t, Unmarshal([]byte("01M00012345XY"), &got))
+	require.Equal(t, redefinesAmount{Units: 123, Cents: 45}, got.Amount)
+	require.Empty(t, got.Text)
+	require.Equal(t, "XY", got.Tail)
+}
+
+func TestRedefinesUnknownField(t *testing.T) {
+	type record struct {
+		Code string `range:"2,4" redefines:"Raw"`
+		Raw  string `range:"2,4"`
+	}
+
+	var got record
+	err := Unmarshal([]byte("01AB"), &got)
+	require.ErrorIs(t, err, ErrInvalidRedefines)
+
+	_, err = Marshal(&record{})
+	require.ErrorIs(t, err, ErrInvalidRedefines)
+}
+
+func TestRedefinesRoundTrip(t *testing.T) {
+	var got redefinesRecord
+	require.NoError(t, Unmarshal([]byte("01A00012345XY"), &got))
+
+	res, err := Marshal(&got)
+	require.NoError(t, err)
+	require.Equal(t, "A00012345XY", string(res))
+
+	// views set apart from the decoded data still conflict
+	got.Code = "9999"
+	_, err = Marshal(&got)
+	require.ErrorIs(t, err, ErrRedefinesConflict)
+}
+
+func TestRedefinesCallbacksRunOnce(t *testing.T) {
+	type rec struct {
+		_    struct{} `record:"prefix=0"`
+		Raw  string   `range:"0,4" overflow:"truncate"`
+		Code string   `range:"0,2" redefines:"Raw"`
+	}
+
+	var truncated []string
+	cfg := DefaultConfig()
+	cfg.OnTruncate = func(field string, original string, res string) {
+		truncated = append(truncated, field)
+	}
+	c := NewCodec(cfg)
+
+	res, err := c.Marshal(&rec{Raw: "AB123", Code: "AB"})
+	require.NoError(t, err)
+	require.Equal(t, "AB12", string(res))
+	require.Equal(t, []string{"Raw"}, truncated)
+}
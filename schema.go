@@ -0,0 +1,640 @@
+package fixedlength
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidSchema      = errors.New("fixedlength: invalid schema")
+	ErrInvalidSchemaValue = errors.New("fixedlength: invalid schema value")
+	ErrFieldValidation    = errors.New("fixedlength: field validation failed")
+)
+
+// FieldType is the type a schema field is decoded to.
+type FieldType int
+
+var (
+	FieldTypeString FieldType = 0 // string
+	FieldTypeInt    FieldType = 1 // int64
+	FieldTypeFloat  FieldType = 2 // float64
+	FieldTypeBool   FieldType = 3 // bool
+	FieldTypeTime   FieldType = 4 // time.Time, laid out by SchemaField.Format
+)
+
+// DefaultTimeFormat lays out FieldTypeTime fields without a format.
+const DefaultTimeFormat = "20060102"
+
+var fieldTypeNames = map[FieldType]string{
+	FieldTypeString: "string",
+	FieldTypeInt:    "int",
+	FieldTypeFloat:  "float",
+	FieldTypeBool:   "bool",
+	FieldTypeTime:   "time",
+}
+
+func (t FieldType) String() string {
+	if name, ok := fieldTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("FieldType(%d)", int(t))
+}
+
+// ParseFieldType returns the field type named name, as printed by FieldType.String.
+func ParseFieldType(name string) (FieldType, error) {
+	for t, n := range fieldTypeNames {
+		if n == name {
+			return t, nil
+		}
+	}
+	return FieldTypeString, fmt.Errorf("unknown type %q", name)
+}
+
+// goType returns the Go type of values of a field type.
+func (t FieldType) goType() (reflect.Type, bool) {
+	switch t {
+	case FieldTypeString:
+		return reflect.TypeOf(""), true
+	case FieldTypeInt:
+		return reflect.TypeOf(int64(0)), true
+	case FieldTypeFloat:
+		return reflect.TypeOf(float64(0)), true
+	case FieldTypeBool:
+		return reflect.TypeOf(false), true
+	case FieldTypeTime:
+		return reflect.TypeOf(time.Time{}), true
+	}
+	return nil, false
+}
+
+// SchemaField is a field of a runtime layout, the counterpart of a tagged struct field.
+type SchemaField struct {
+	Name      string
+	Offset    int // 0-based, in the codec position unit
+	Length    int
+	Type      FieldType
+	Decimals  int
+	Alignment AlignmentType // AlignmentTypeNone means the codec alignment
+	// GoType, when set, replaces Type. Values are then decoded like struct fields
+	// of that type, through converters, the unmarshaler interfaces or their kind.
+	GoType reflect.Type
+	// Format is the time.Parse layout of FieldTypeTime fields, DefaultTimeFormat if empty.
+	Format string
+
+	// Default is decoded instead of blank fields.
+	Default string
+	// Required rejects blank fields on decode.
+	Required bool
+	// Pattern, when set, must match the non blank fields on decode.
+	Pattern string
+	// Values, when set, lists the allowed non blank values on decode.
+	Values []string
+
+	// Usage stores numbers as binary or packed decimals, which needs byte positions.
+	Usage Usage
+	// Sign is how the sign of numbers is stored, separate signs take a character of
+	// the field and need display usage.
+	Sign Sign
+	// Redefines names the field or group this field is an alternative view of, it may
+	// overlap it. All views are decoded, a view is only encoded when it has a value and
+	// the fields it overlaps have none.
+	Redefines string
+	// DependsOn names the field holding the number of occurrences of a variable table,
+	// like OCCURS DEPENDING ON, Occurrence is the 1-based occurrence of this field.
+	// Occurrences past that number are neither decoded nor encoded.
+	DependsOn  string
+	Occurrence int
+}
+
+// isTime reports whether f is laid out with a time format.
+func (f SchemaField) isTime() bool {
+	return f.GoType == nil && f.Type == FieldTypeTime
+}
+
+func (f SchemaField) timeFormat() string {
+	if f.Format == "" {
+		return DefaultTimeFormat
+	}
+	return f.Format
+}
+
+var patternCache sync.Map // pattern to *regexp.Regexp
+
+func patternRegexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patternCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patternCache.Store(pattern, re)
+	return re, nil
+}
+
+// check validates the trimmed text of a decoded field.
+func (f SchemaField) check(value string) error {
+	if value == "" {
+		if f.Required {
+			return fmt.Errorf("%w: %s is required", ErrFieldValidation, f.Name)
+		}
+		return nil
+	}
+
+	if len(f.Values) > 0 && !slices.Contains(f.Values, value) {
+		return fmt.Errorf("%w: %s is %q, want one of %q", ErrFieldValidation, f.Name, value, f.Values)
+	}
+
+	if f.Pattern != "" {
+		re, err := patternRegexp(f.Pattern)
+		if err != nil {
+			return fmt.Errorf("%w: field %s has invalid pattern: %w", ErrInvalidSchema, f.Name, err)
+		}
+		if !re.MatchString(value) {
+			return fmt.Errorf("%w: %s is %q, want a match of %s", ErrFieldValidation, f.Name, value, f.Pattern)
+		}
+	}
+	return nil
+}
+
+// valueType returns the Go type of the field values.
+func (f SchemaField) valueType() (reflect.Type, error) {
+	if f.GoType != nil {
+		return f.GoType, nil
+	}
+	t, ok := f.Type.goType()
+	if !ok {
+		return nil, fmt.Errorf("%w: field %s has unknown type %s", ErrInvalidSchema, f.Name, f.Type)
+	}
+	return t, nil
+}
+
+// declaresGroup reports whether fields hold the field name, or the fields of the group
+// name, like the CUST-SINCE.SINCE-YEAR and CUST-TABLE(1).CODE fields of copybooks.
+func declaresGroup(fields []SchemaField, name string) bool {
+	for _, f := range fields {
+		if f.Name == name || strings.HasPrefix(f.Name, name+".") || strings.HasPrefix(f.Name, name+"(") {
+			return true
+		}
+	}
+	return false
+}
+
+// tag returns the field tag the reflection path would parse for f.
+func (f SchemaField) tag() tag {
+	decimals := f.Decimals
+	if decimals == 0 {
+		decimals = -1
+	}
+	return tag{
+		fromPos:  f.Offset,
+		toPos:    f.Offset + f.Length,
+		align:    f.Alignment,
+		decimals: decimals,
+		fill:     -1,
+		usage:    f.Usage,
+		sign:     f.Sign,
+		name:     f.Name,
+	}
+}
+
+// Schema is a record layout defined at runtime, for layouts that have no Go struct.
+// Records are decoded into a map or a Record and encoded back with the same
+// conversions as tagged struct fields. Offsets are absolute: unlike Marshal,
+// encoding starts at position 0, with the record type if the schema has one.
+type Schema struct {
+	Fields []SchemaField
+	// Length pads encoded records up to that many units, 0 means up to the last field.
+	Length int
+	// RecordType, when set, is written at the start of encoded records, and decoded
+	// records must start with it. Fields start after it.
+	RecordType string
+}
+
+// recordTypeTag returns the constant field of the record type, if any.
+func (s *Schema) recordTypeTag() (tag, bool) {
+	if s.RecordType == "" {
+		return tag{}, false
+	}
+	return tag{
+		toPos:    utf8.RuneCountInString(s.RecordType),
+		decimals: -1,
+		fill:     -1,
+		constant: &s.RecordType,
+		name:     "record type",
+	}, true
+}
+
+// Validate checks the schema: unique names, known types, positive lengths,
+// and no overlapping fields.
+func (s *Schema) Validate() error {
+	names := map[string]bool{}
+	for i, f := range s.Fields {
+		if f.Name == "" {
+			return fmt.Errorf("%w: field at offset %d has no name", ErrInvalidSchema, f.Offset)
+		}
+		if names[f.Name] {
+			return fmt.Errorf("%w: duplicate field %s", ErrInvalidSchema, f.Name)
+		}
+		names[f.Name] = true
+
+		if f.Offset < 0 || f.Length <= 0 {
+			return fmt.Errorf("%w: field %s has invalid range (%d,%d)", ErrInvalidSchema, f.Name, f.Offset, f.Offset+f.Length)
+		}
+		if _, err := f.valueType(); err != nil {
+			return err
+		}
+		if f.Pattern != "" {
+			if _, err := patternRegexp(f.Pattern); err != nil {
+				return fmt.Errorf("%w: field %s has invalid pattern: %w", ErrInvalidSchema, f.Name, err)
+			}
+		}
+		if f.Usage != UsageDisplay && f.Type != FieldTypeInt && f.Type != FieldTypeFloat {
+			return fmt.Errorf("%w: field %s has a binary usage but is not a number", ErrInvalidSchema, f.Name)
+		}
+		if f.Sign != SignOverpunch && f.Type != FieldTypeInt && f.Type != FieldTypeFloat {
+			return fmt.Errorf("%w: field %s has a sign but is not a number", ErrInvalidSchema, f.Name)
+		}
+		if f.Sign.separate() && (f.Usage != UsageDisplay || f.Length < 2) {
+			return fmt.Errorf("%w: field %s needs display usage and a digit for its separate sign", ErrInvalidSchema, f.Name)
+		}
+		if f.Usage == UsageBinary && f.Length > 8 {
+			return fmt.Errorf("%w: field %s has a binary usage over %d bytes, at most 8 fit", ErrInvalidSchema, f.Name, f.Length)
+		}
+		if f.Redefines != "" && !declaresGroup(s.Fields[:i], f.Redefines) {
+			return fmt.Errorf("%w: field %s redefines %s, which must be declared before it", ErrInvalidSchema, f.Name, f.Redefines)
+		}
+		if f.DependsOn != "" && !names[f.DependsOn] {
+			return fmt.Errorf("%w: field %s depends on %s, which must be declared before it", ErrInvalidSchema, f.Name, f.DependsOn)
+		}
+	}
+
+	sorted := make([]SchemaField, 0, len(s.Fields)+1)
+	for _, f := range s.Fields {
+		// views overlap the fields they redefine
+		if f.Redefines == "" {
+			sorted = append(sorted, f)
+		}
+	}
+	if rt, ok := s.recordTypeTag(); ok {
+		sorted = append(sorted, SchemaField{Name: rt.name, Length: rt.Len()})
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Offset < sorted[j].Offset
+	})
+	for i := 1; i < len(sorted); i++ {
+		prev, f := sorted[i-1], sorted[i]
+		if f.Offset < prev.Offset+prev.Length {
+			return fmt.Errorf("%w: %s (%d,%d) and %s (%d,%d)", ErrLayoutOverlap,
+				prev.Name, prev.Offset, prev.Offset+prev.Length, f.Name, f.Offset, f.Offset+f.Length)
+		}
+	}
+
+	if end := s.end(); s.Length > 0 && s.Length < end {
+		return fmt.Errorf("%w: fields end at %d, after the record length %d", ErrInvalidSchema, end, s.Length)
+	}
+	return nil
+}
+
+// end returns the position after the last field.
+func (s *Schema) end() int {
+	end := utf8.RuneCountInString(s.RecordType)
+	for _, f := range s.Fields {
+		if f.Offset+f.Length > end {
+			end = f.Offset + f.Length
+		}
+	}
+	return end
+}
+
+// Record is a decoded record keeping the schema field order.
+type Record []RecordField
+
+// RecordField is a named value of a Record.
+type RecordField struct {
+	Name  string
+	Value any
+}
+
+// Get returns the value of the field name.
+func (r Record) Get(name string) (any, bool) {
+	for _, f := range r {
+		if f.Name == name {
+			return f.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Map returns the record values by field name.
+func (r Record) Map() map[string]any {
+	m := make(map[string]any, len(r))
+	for _, f := range r {
+		m[f.Name] = f.Value
+	}
+	return m
+}
+
+// UnmarshalMap decodes data laid out by s into a map of field values.
+func UnmarshalMap(data []byte, s *Schema) (map[string]any, error) {
+	return defaultCodec().UnmarshalMap(data, s)
+}
+
+// UnmarshalRecord decodes data laid out by s into a Record in schema order.
+func UnmarshalRecord(data []byte, s *Schema) (Record, error) {
+	return defaultCodec().UnmarshalRecord(data, s)
+}
+
+// MarshalMap encodes the values of m, by field name, in the layout of s.
+// Missing values are encoded as the zero value of their field.
+func MarshalMap(s *Schema, m map[string]any) ([]byte, error) {
+	return defaultCodec().MarshalMap(s, m)
+}
+
+// MarshalRecord encodes r in the layout of s, fields are matched by name.
+func MarshalRecord(s *Schema, r Record) ([]byte, error) {
+	return defaultCodec().MarshalRecord(s, r)
+}
+
+// UnmarshalMap decodes data like the package level UnmarshalMap, using the codec configuration.
+func (c *Codec) UnmarshalMap(data []byte, s *Schema) (map[string]any, error) {
+	r, err := c.UnmarshalRecord(data, s)
+	if err != nil {
+		return nil, err
+	}
+	return r.Map(), nil
+}
+
+// UnmarshalRecord decodes data like the package level UnmarshalRecord, using the codec configuration.
+func (c *Codec) UnmarshalRecord(data []byte, s *Schema) (Record, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	rr, err := c.newRecordReader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if rt, ok := s.recordTypeTag(); ok {
+		if err := rt.Validate(rr.Len()); err != nil {
+			return nil, fmt.Errorf("failed to validate %s : %w", rt.name, err)
+		}
+		raw, err := rr.field(rt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s : %w", rt.name, err)
+		}
+		if err := c.checkLiteral(raw, rt); err != nil {
+			return nil, fmt.Errorf("invalid %s : %w", rt.name, err)
+		}
+	}
+
+	r := make(Record, 0, len(s.Fields))
+	for _, f := range s.Fields {
+		if f.DependsOn != "" {
+			count, _ := r.Get(f.DependsOn)
+			n, err := occurrences(count)
+			if err != nil {
+				return nil, fmt.Errorf("invalid occurrences of field %s : %w", f.Name, err)
+			}
+			if f.Occurrence > n {
+				continue
+			}
+		}
+
+		t := f.tag()
+		if err := c.checkFieldTag(t); err != nil {
+			return nil, fmt.Errorf("invalid field %s (%s) : %w", f.Name, t, err)
+		}
+		if err := t.Validate(rr.Len()); err != nil {
+			return nil, fmt.Errorf("failed to validate field %s (%s) : %w", f.Name, t, err)
+		}
+
+		vt, _ := f.valueType()
+		v := reflect.New(vt).Elem()
+
+		if f.Usage != UsageDisplay {
+			num, err := decodeUsage(rr.raw(t), t)
+			if err == nil {
+				err = c.setFieldValue(v, num, t)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to set field value %s (%s) : %w", f.Name, t, err)
+			}
+			r = append(r, RecordField{Name: f.Name, Value: v.Interface()})
+			continue
+		}
+
+		raw, err := rr.field(t)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode field %s (%s) : %w", f.Name, t, err)
+		}
+
+		value := c.trimField(raw, v, t)
+		if value == "" {
+			value = f.Default
+		}
+		if err := f.check(value); err != nil {
+			return nil, err
+		}
+
+		if err := c.setSchemaValue(f, v, value, t); err != nil {
+			return nil, fmt.Errorf("failed to set field value %s (%s) : %w", f.Name, t, err)
+		}
+		r = append(r, RecordField{Name: f.Name, Value: v.Interface()})
+	}
+	return r, nil
+}
+
+// setSchemaValue decodes value into v, times with the field format.
+func (c *Codec) setSchemaValue(f SchemaField, v reflect.Value, value string, t tag) error {
+	if !f.isTime() {
+		return c.setFieldValue(v, value, t)
+	}
+	if value == "" {
+		return nil
+	}
+	tm, err := time.Parse(f.timeFormat(), value)
+	if err != nil {
+		return err
+	}
+	v.Set(reflect.ValueOf(tm))
+	return nil
+}
+
+// MarshalMap encodes m like the package level MarshalMap, using the codec configuration.
+func (c *Codec) MarshalMap(s *Schema, m map[string]any) ([]byte, error) {
+	return c.marshalSchema(s, func(name string) any { return m[name] })
+}
+
+// MarshalRecord encodes r like the package level MarshalRecord, using the codec configuration.
+func (c *Codec) MarshalRecord(s *Schema, r Record) ([]byte, error) {
+	return c.marshalSchema(s, func(name string) any {
+		v, _ := r.Get(name)
+		return v
+	})
+}
+
+func (c *Codec) marshalSchema(s *Schema, valueOf func(name string) any) ([]byte, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	fields := make([]fieldToMarshal, 0, len(s.Fields)+1)
+	if rt, ok := s.recordTypeTag(); ok {
+		fields = append(fields, fieldToMarshal{tag: rt, value: reflect.ValueOf(s.RecordType)})
+	}
+
+	var views, absent []fieldToMarshal
+	for _, f := range s.Fields {
+		value := valueOf(f.Name)
+		if f.DependsOn != "" {
+			n, err := occurrences(valueOf(f.DependsOn))
+			if err != nil {
+				return nil, fmt.Errorf("invalid occurrences of field %s : %w", f.Name, err)
+			}
+			if f.Occurrence > n {
+				continue
+			}
+		}
+		if f.Redefines != "" && value == nil {
+			continue
+		}
+
+		v, err := schemaValue(f, value)
+		if err != nil {
+			return nil, err
+		}
+		if f.isTime() {
+			// times are written as text in the field format, zero times blank
+			text := ""
+			if tm := v.Interface().(time.Time); !tm.IsZero() {
+				text = tm.Format(f.timeFormat())
+			}
+			v = reflect.ValueOf(text)
+		}
+
+		t := f.tag()
+		if err := c.checkFieldTag(t); err != nil {
+			return nil, fmt.Errorf("invalid field %s (%s) : %w", f.Name, t, err)
+		}
+
+		fm := fieldToMarshal{tag: t, value: v}
+		switch {
+		case f.Redefines != "":
+			views = append(views, fm)
+		case value == nil:
+			absent = append(absent, fm)
+		default:
+			fields = append(fields, fm)
+		}
+	}
+
+	// fields with a value win, then views, then the zero values of the others
+	for _, group := range [][]fieldToMarshal{views, absent} {
+		for _, fm := range group {
+			if !overlapsAny(fm.tag, fields) {
+				fields = append(fields, fm)
+			}
+		}
+	}
+
+	length := s.Length
+	if length == 0 {
+		length = -1
+	}
+	record, err := c.writeFields(fields, 0, length)
+	if err != nil {
+		return nil, err
+	}
+	return c.encodeRecord(record)
+}
+
+// schemaValue converts a value to the Go type of f. Numbers convert between
+// their kinds when the value is kept, so 12.75 isn't written to an int field as 12;
+// nil is the zero value.
+func schemaValue(f SchemaField, value any) (reflect.Value, error) {
+	vt, _ := f.valueType()
+	res := reflect.New(vt).Elem()
+	if value == nil {
+		return res, nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch {
+	case v.Type().AssignableTo(vt):
+		res.Set(v)
+	case isNumberKind(v.Kind()) && isNumberKind(vt.Kind()):
+		res.Set(v.Convert(vt))
+		if !isFloatKind(vt.Kind()) && !sameNumber(v, res) {
+			return res, fmt.Errorf("%w: field %s is %s, got %v", ErrInvalidSchemaValue, f.Name, vt, value)
+		}
+	default:
+		return res, fmt.Errorf("%w: field %s is %s, got %T", ErrInvalidSchemaValue, f.Name, vt, value)
+	}
+	return res, nil
+}
+
+// overlapsAny reports whether the range of t overlaps one of fields.
+func overlapsAny(t tag, fields []fieldToMarshal) bool {
+	for _, f := range fields {
+		if t.fromPos < f.tag.toPos && f.tag.fromPos < t.toPos {
+			return true
+		}
+	}
+	return false
+}
+
+// occurrences converts the value of a DependsOn field to a count, nil is none.
+func occurrences(value any) (int, error) {
+	if value == nil {
+		return 0, nil
+	}
+	v := reflect.ValueOf(value)
+	if !isNumberKind(v.Kind()) {
+		return 0, fmt.Errorf("%w: occurrences are %T", ErrInvalidSchemaValue, value)
+	}
+	return int(v.Convert(reflect.TypeOf(0)).Int()), nil
+}
+
+// sameNumber reports whether res, converted from the number v, holds the same value.
+func sameNumber(v reflect.Value, res reflect.Value) bool {
+	if !res.Convert(v.Type()).Equal(v) {
+		return false
+	}
+	// conversions between signed and unsigned kinds keep the bits, not the sign
+	return isNegative(v) == isNegative(res)
+}
+
+func isNegative(v reflect.Value) bool {
+	switch {
+	case v.CanInt():
+		return v.Int() < 0
+	case v.CanFloat():
+		return v.Float() < 0
+	}
+	return false
+}
+
+func isFloatKind(k reflect.Kind) bool {
+	return k == reflect.Float32 || k == reflect.Float64
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
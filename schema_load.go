@@ -0,0 +1,171 @@
+package fixedlength
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// schemaFile is the JSON and YAML representation of a Schema.
+type schemaFile struct {
+	RecordType string            `json:"recordType" yaml:"recordType"`
+	Length     int               `json:"length" yaml:"length"`
+	Fields     []schemaFileField `json:"fields" yaml:"fields"`
+}
+
+type schemaFileField struct {
+	Name     string   `json:"name" yaml:"name"`
+	Offset   *int     `json:"offset" yaml:"offset"`
+	Length   int      `json:"length" yaml:"length"`
+	Type     string   `json:"type" yaml:"type"`
+	Decimals int      `json:"decimals" yaml:"decimals"`
+	Align    string   `json:"align" yaml:"align"`
+	Format   string   `json:"format" yaml:"format"`
+	Default  string   `json:"default" yaml:"default"`
+	Required bool     `json:"required" yaml:"required"`
+	Pattern  string   `json:"pattern" yaml:"pattern"`
+	Values   []string `json:"values" yaml:"values"`
+
+	Usage     string `json:"usage" yaml:"usage"`
+	Sign      string `json:"sign" yaml:"sign"`
+	Redefines string `json:"redefines" yaml:"redefines"`
+}
+
+// LoadSchema reads a Schema from a JSON or YAML document, e.g.
+//
+//	recordType: "6"
+//	length: 94
+//	fields:
+//	  - {name: account, offset: 1, length: 17}
+//	  - {name: amount, length: 10, type: int, decimals: 2, align: right}
+//	  - {name: booked, length: 8, type: time, format: "20060102", required: true}
+//	  - {name: currency, length: 3, default: USD, pattern: "^[A-Z]{3}$"}
+//
+// Offsets are 0-based, a field without one starts after the previous field, or after
+// the record type, or where the field it redefines starts. Types are string
+// (the default), int, float, bool and time; numbers may have a binary usage
+// (comp or comp-3) and a sign (leading or trailing for a separate sign character,
+// none for unsigned numbers).
+// Unknown keys, types and alignments are rejected, like overlapping fields.
+func LoadSchema(r io.Reader) (*Schema, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var file schemaFile
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&file)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&file)
+		if err == io.EOF {
+			err = nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSchema, err)
+	}
+
+	s, err := file.schema()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (file schemaFile) schema() (*Schema, error) {
+	if len(file.Fields) == 0 {
+		return nil, fmt.Errorf("%w: no fields", ErrInvalidSchema)
+	}
+
+	s := &Schema{RecordType: file.RecordType, Length: file.Length}
+	nextPos := len([]rune(file.RecordType))
+	starts := map[string]int{}
+	for i, ff := range file.Fields {
+		pos := nextPos
+		if ff.Redefines != "" {
+			start, ok := starts[ff.Redefines]
+			if !ok {
+				return nil, fmt.Errorf("%w: field %d (%s): redefines unknown field %s", ErrInvalidSchema, i+1, ff.Name, ff.Redefines)
+			}
+			pos = start
+		}
+
+		f, err := ff.schemaField(pos)
+		if err != nil {
+			return nil, fmt.Errorf("%w: field %d (%s): %w", ErrInvalidSchema, i+1, ff.Name, err)
+		}
+		s.Fields = append(s.Fields, f)
+		starts[f.Name] = f.Offset
+		if f.Redefines == "" {
+			nextPos = f.Offset + f.Length
+		}
+	}
+	return s, nil
+}
+
+func (ff schemaFileField) schemaField(nextPos int) (SchemaField, error) {
+	f := SchemaField{
+		Name:     ff.Name,
+		Offset:   nextPos,
+		Length:   ff.Length,
+		Decimals: ff.Decimals,
+		Format:   ff.Format,
+		Default:  ff.Default,
+		Required: ff.Required,
+		Pattern:  ff.Pattern,
+		Values:   ff.Values,
+
+		Redefines: ff.Redefines,
+	}
+	if ff.Offset != nil {
+		f.Offset = *ff.Offset
+	}
+	if ff.Length <= 0 {
+		return f, fmt.Errorf("length must be positive, got %d", ff.Length)
+	}
+	if ff.Decimals < 0 {
+		return f, fmt.Errorf("decimals must not be negative, got %d", ff.Decimals)
+	}
+
+	if ff.Type != "" {
+		t, err := ParseFieldType(ff.Type)
+		if err != nil {
+			return f, err
+		}
+		f.Type = t
+	}
+	if ff.Format != "" && f.Type != FieldTypeTime {
+		return f, fmt.Errorf("format is only supported by time fields")
+	}
+
+	align, err := parseAlignTag(ff.Align)
+	if err != nil {
+		return f, err
+	}
+	f.Alignment = align
+
+	usage, err := parseUsageTag(ff.Usage)
+	if err != nil {
+		return f, err
+	}
+	f.Usage = usage
+
+	sign, err := parseSignTag(ff.Sign)
+	if err != nil {
+		return f, err
+	}
+	f.Sign = sign
+
+	return f, nil
+}
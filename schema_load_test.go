@@ -0,0 +1,163 @@
+package fixedlength
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testSchemaYAML = `
+recordType: "6"
+length: 30
+fields:
+  - {name: account, length: 6}
+  - {name: amount, length: 8, type: float, decimals: 2, align: right}
+  - name: booked
+    length: 8
+    type: time
+    required: true
+  - name: currency
+    offset: 23
+    length: 3
+    default: USD
+    pattern: "^[A-Z]{3}$"
+  - {name: status, length: 1, values: [A, C]}
+`
+
+func TestLoadSchemaYAML(t *testing.T) {
+	s, err := LoadSchema(strings.NewReader(testSchemaYAML))
+	require.NoError(t, err)
+	require.Equal(t, "6", s.RecordType)
+	require.Equal(t, 30, s.Length)
+	require.Equal(t, SchemaField{Name: "amount", Offset: 7, Length: 8, Type: FieldTypeFloat, Decimals: 2, Alignment: AlignmentTypeRight}, s.Fields[1])
+	require.Equal(t, 15, s.Fields[2].Offset)
+	require.Equal(t, 26, s.Fields[4].Offset)
+
+	data := "6ACME  0001500020240229   A   "
+	m, err := UnmarshalMap([]byte(data), s)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		"account":  "ACME",
+		"amount":   150.0,
+		"booked":   time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		"currency": "USD",
+		"status":   "A",
+	}, m)
+
+	res, err := MarshalMap(s, m)
+	require.NoError(t, err)
+	require.Equal(t, "6ACME  0001500020240229USDA   ", string(res))
+}
+
+func TestLoadSchemaValidations(t *testing.T) {
+	s, err := LoadSchema(strings.NewReader(testSchemaYAML))
+	require.NoError(t, err)
+
+	_, err = UnmarshalMap([]byte("7ACME  0001500020240229   A   "), s)
+	require.ErrorIs(t, err, ErrConstantMismatch)
+
+	_, err = UnmarshalMap([]byte("6ACME  00015000           A   "), s)
+	require.ErrorIs(t, err, ErrFieldValidation)
+	require.Contains(t, err.Error(), "booked is required")
+
+	_, err = UnmarshalMap([]byte("6ACME  0001500020240229eurA   "), s)
+	require.ErrorIs(t, err, ErrFieldValidation)
+
+	_, err = UnmarshalMap([]byte("6ACME  0001500020240229EURX   "), s)
+	require.ErrorIs(t, err, ErrFieldValidation)
+	require.Contains(t, err.Error(), `status is "X", want one of ["A" "C"]`)
+}
+
+func TestLoadSchemaJSON(t *testing.T) {
+	s, err := LoadSchema(strings.NewReader(`{
+		"fields": [
+			{"name": "id", "offset": 0, "length": 4, "type": "int"},
+			{"name": "name", "length": 10, "align": "left"}
+		]
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, []SchemaField{
+		{Name: "id", Length: 4, Type: FieldTypeInt},
+		{Name: "name", Offset: 4, Length: 10, Alignment: AlignmentTypeLeft},
+	}, s.Fields)
+}
+
+func TestLoadSchemaErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		err    error
+		msg    string
+	}{
+		{
+			name:   "unknown type",
+			schema: "fields:\n  - {name: id, length: 4}\n  - {name: amount, length: 4, type: money}\n",
+			err:    ErrInvalidSchema,
+			msg:    `field 2 (amount): unknown type "money"`,
+		},
+		{
+			name:   "overlap",
+			schema: "fields:\n  - {name: id, length: 4}\n  - {name: amount, offset: 2, length: 4}\n",
+			err:    ErrLayoutOverlap,
+			msg:    "id (0,4) and amount (2,6)",
+		},
+		{
+			name:   "overlapping record type",
+			schema: "recordType: \"01\"\nfields:\n  - {name: id, offset: 1, length: 4}\n",
+			err:    ErrLayoutOverlap,
+			msg:    "record type (0,2) and id (1,5)",
+		},
+		{
+			name:   "unknown alignment",
+			schema: `{"fields": [{"name": "id", "length": 4, "align": "middle"}]}`,
+			err:    ErrInvalidSchema,
+			msg:    "field 1 (id): invalid align type: middle",
+		},
+		{
+			name:   "unknown key",
+			schema: "fields:\n  - {name: id, lenght: 4}\n",
+			err:    ErrInvalidSchema,
+			msg:    "line 2: field lenght not found",
+		},
+		{
+			name:   "missing length",
+			schema: "fields:\n  - {name: id}\n",
+			err:    ErrInvalidSchema,
+			msg:    "field 1 (id): length must be positive, got 0",
+		},
+		{
+			name:   "duplicate",
+			schema: "fields:\n  - {name: id, length: 4}\n  - {name: id, length: 4}\n",
+			err:    ErrInvalidSchema,
+			msg:    "duplicate field id",
+		},
+		{
+			name:   "format on a string",
+			schema: "fields:\n  - {name: id, length: 4, format: x}\n",
+			err:    ErrInvalidSchema,
+			msg:    "format is only supported by time fields",
+		},
+		{
+			name:   "invalid pattern",
+			schema: "fields:\n  - {name: id, length: 4, pattern: \"[\"}\n",
+			err:    ErrInvalidSchema,
+			msg:    "field id has invalid pattern",
+		},
+		{
+			name:   "empty",
+			schema: "",
+			err:    ErrInvalidSchema,
+			msg:    "no fields",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadSchema(strings.NewReader(tt.schema))
+			require.ErrorIs(t, err, tt.err)
+			require.Contains(t, err.Error(), tt.msg)
+		})
+	}
+}
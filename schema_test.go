@@ -0,0 +1,137 @@
+package fixedlength
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testSchema() *Schema {
+	return &Schema{
+		Length: 25,
+		Fields: []SchemaField{
+			{Name: "type", Offset: 0, Length: 1},
+			{Name: "name", Offset: 1, Length: 6},
+			{Name: "amount", Offset: 7, Length: 6, Type: FieldTypeInt},
+			{Name: "rate", Offset: 13, Length: 5, Type: FieldTypeFloat, Decimals: 2},
+			{Name: "active", Offset: 18, Length: 5, Type: FieldTypeBool},
+		},
+	}
+}
+
+func TestSchemaRoundTrip(t *testing.T) {
+	data := "6ACME  00004200150true   "
+
+	m, err := UnmarshalMap([]byte(data), testSchema())
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		"type":   "6",
+		"name":   "ACME",
+		"amount": int64(42),
+		"rate":   1.5,
+		"active": true,
+	}, m)
+
+	res, err := MarshalMap(testSchema(), m)
+	require.NoError(t, err)
+	require.Equal(t, data, string(res))
+
+	r, err := UnmarshalRecord([]byte(data), testSchema())
+	require.NoError(t, err)
+	require.Equal(t, []string{"type", "name", "amount", "rate", "active"}, recordNames(r))
+
+	res, err = MarshalRecord(testSchema(), r)
+	require.NoError(t, err)
+	require.Equal(t, data, string(res))
+}
+
+func recordNames(r Record) []string {
+	var names []string
+	for _, f := range r {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+func TestSchemaMarshalValues(t *testing.T) {
+	// numbers convert between kinds, missing values are zero
+	res, err := MarshalMap(testSchema(), map[string]any{"type": "6", "amount": 7, "active": false})
+	require.NoError(t, err)
+	require.Equal(t, "6      00000700000false  ", string(res))
+
+	_, err = MarshalMap(testSchema(), map[string]any{"amount": "7"})
+	require.ErrorIs(t, err, ErrInvalidSchemaValue)
+	require.Contains(t, err.Error(), "field amount is int64, got string")
+
+	// integral floats convert, others don't lose their fraction silently
+	res, err = MarshalMap(testSchema(), map[string]any{"type": "6", "amount": 7.0})
+	require.NoError(t, err)
+	require.Equal(t, "6      00000700000false  ", string(res))
+
+	_, err = MarshalMap(testSchema(), map[string]any{"amount": 12.75})
+	require.ErrorIs(t, err, ErrInvalidSchemaValue)
+	require.Contains(t, err.Error(), "field amount is int64, got 12.75")
+}
+
+func TestSchemaGoType(t *testing.T) {
+	s := &Schema{Fields: []SchemaField{
+		{Name: "id", Offset: 0, Length: 4, Type: FieldTypeInt},
+		{Name: "at", Offset: 4, Length: 8, GoType: reflect.TypeOf(time.Time{})},
+	}}
+
+	c := NewCodec(DefaultConfig())
+	RegisterCodecConverter(c,
+		func(v time.Time, _ FieldInfo) (string, error) { return v.Format("20060102"), nil },
+		func(s string, _ FieldInfo) (time.Time, error) { return time.Parse("20060102", s) },
+	)
+
+	m, err := c.UnmarshalMap([]byte("000120240229"), s)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), m["at"])
+
+	res, err := c.MarshalMap(s, m)
+	require.NoError(t, err)
+	require.Equal(t, "000120240229", string(res))
+}
+
+func TestSchemaValidate(t *testing.T) {
+	s := testSchema()
+	s.Fields = append(s.Fields, SchemaField{Name: "code", Offset: 20, Length: 2})
+	require.ErrorIs(t, s.Validate(), ErrLayoutOverlap)
+	require.EqualError(t, s.Validate(), "fixedlength: overlapping ranges: active (18,23) and code (20,22)")
+
+	s = testSchema()
+	s.Fields = append(s.Fields, SchemaField{Name: "name", Offset: 23, Length: 2})
+	require.ErrorIs(t, s.Validate(), ErrInvalidSchema)
+
+	s = testSchema()
+	s.Fields[0].Type = FieldType(42)
+	require.ErrorIs(t, s.Validate(), ErrInvalidSchema)
+
+	s = testSchema()
+	s.Length = 20
+	require.ErrorIs(t, s.Validate(), ErrInvalidSchema)
+
+	s = testSchema()
+	s.Fields = append(s.Fields, SchemaField{Name: "view", Offset: 1, Length: 6, Redefines: "missing"})
+	require.ErrorIs(t, s.Validate(), ErrInvalidSchema)
+	require.ErrorContains(t, s.Validate(), "field view redefines missing, which must be declared before it")
+
+	// views may redefine the fields of a group
+	s = &Schema{Fields: []SchemaField{
+		{Name: "date.year", Length: 4},
+		{Name: "date.month", Offset: 4, Length: 2},
+		{Name: "date-n", Length: 6, Type: FieldTypeInt, Redefines: "date"},
+	}}
+	require.NoError(t, s.Validate())
+
+	s = &Schema{Fields: []SchemaField{{Name: "n", Length: 10, Type: FieldTypeInt, Usage: UsageBinary}}}
+	require.ErrorIs(t, s.Validate(), ErrInvalidSchema)
+	_, err := MarshalMap(s, map[string]any{"n": 1})
+	require.ErrorIs(t, err, ErrInvalidSchema)
+
+	_, err = UnmarshalMap([]byte("6ACME"), testSchema())
+	require.Error(t, err)
+}
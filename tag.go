@@ -13,6 +13,7 @@ var (
 	ErrTagEmpty              = errors.New("tag is empty")
 	ErrTagInvalidRangeValues = errors.New("invalid range values")
 	ErrTagInvalidUpperBound  = errors.New("invalid upper bound")
+	ErrTagConflictingLayout  = errors.New("conflicting layout tags")
 )
 
 type tag struct {
@@ -21,6 +22,28 @@ type tag struct {
 	flags    flags
 	align    AlignmentType
 	decimals int
+	fill     int // -1 means the codec default
+	trim     TrimType
+	cutset   string  // empty means the field filler
+	charset  Charset // nil means the codec charset
+
+	transform *TextTransform // nil means the codec transform
+	allowed   *string        // nil means the transform allowed characters
+
+	overflow    OverflowType
+	hasOverflow bool // false means the codec overflow policy
+
+	discriminator string // name of the field selecting the variant of an interface field
+
+	defaultValue *string // nil means blank fields are decoded as is
+	constant     *string // literal written by Marshal and verified by Unmarshal
+
+	usage Usage // how numbers are stored
+	sign  Sign  // how the sign of numbers is stored
+
+	timeFormat string // layout of time.Time fields, empty means their text marshaling
+
+	name string // struct field name, set by the caller for error reporting
 }
 
 func (t tag) Len() int {
@@ -41,6 +64,8 @@ func (t tag) Validate(maxPos int) error {
 
 type flags struct {
 	optional bool
+	omitzero bool // zero values are written blank, blank fields decode to zero
+	filler   bool // FILLER range, written blank
 }
 
 func (t tag) String() string {
@@ -52,6 +77,12 @@ func (f flags) String() string {
 }
 
 func parseFieldTag(t reflect.StructTag) (tag, error) {
+	return parseFieldTagAt(t, 0)
+}
+
+// parseFieldTagAt parses a field tag, nextPos is where the previous field ended
+// and is used by fields laid out with `len` only.
+func parseFieldTagAt(t reflect.StructTag, nextPos int) (tag, error) {
 	res := tag{}
 
 	flagsTag := t.Get("flags")
@@ -75,8 +106,76 @@ func parseFieldTag(t reflect.StructTag) (tag, error) {
 	}
 	res.decimals = decimals
 
-	rangeTag := t.Get("range")
-	start, end, err := parseRangeTag(rangeTag)
+	fillTag, ok := t.Lookup("fill")
+	fill, err := parseFillTag(fillTag, ok)
+	if err != nil {
+		return res, err
+	}
+	res.fill = fill
+
+	trimTag := t.Get("trim")
+	trim, cutset, err := parseTrimTag(trimTag)
+	if err != nil {
+		return res, err
+	}
+	res.trim = trim
+	res.cutset = cutset
+
+	if transformTag, ok := t.Lookup("transform"); ok {
+		tt, err := parseTransformTag(transformTag)
+		if err != nil {
+			return res, err
+		}
+		res.transform = &tt
+	}
+
+	if allowTag, ok := t.Lookup("allow"); ok {
+		if _, err := allowedRegexp(allowTag); err != nil {
+			return res, err
+		}
+		res.allowed = &allowTag
+	}
+
+	overflow, hasOverflow, err := parseOverflowTag(t.Get("overflow"))
+	if err != nil {
+		return res, err
+	}
+	res.overflow = overflow
+	res.hasOverflow = hasOverflow
+
+	if charsetTag := t.Get("charset"); charsetTag != "" {
+		cs, ok := CharsetByName(charsetTag)
+		if !ok {
+			return res, fmt.Errorf("unknown charset: %s", charsetTag)
+		}
+		res.charset = cs
+	}
+
+	res.discriminator = t.Get("discriminator")
+
+	if defaultTag, ok := t.Lookup("default"); ok {
+		res.defaultValue = &defaultTag
+	}
+
+	if constTag, ok := t.Lookup("const"); ok {
+		res.constant = &constTag
+	}
+
+	usage, err := parseUsageTag(t.Get("usage"))
+	if err != nil {
+		return res, err
+	}
+	res.usage = usage
+
+	sign, err := parseSignTag(t.Get("sign"))
+	if err != nil {
+		return res, err
+	}
+	res.sign = sign
+
+	res.timeFormat = t.Get("format")
+
+	start, end, err := parseLayoutTags(t, nextPos)
 	if err != nil {
 		return res, err
 	}
@@ -86,6 +185,87 @@ func parseFieldTag(t reflect.StructTag) (tag, error) {
 	return res, nil
 }
 
+// parseLayoutTags resolves the field position from one of
+// `range:"<from>,<to>"` (0-based, to excluded),
+// `pos:"<first>,<last>"` (1-based, both included, as printed in most specs),
+// `pos:"<first>" len:"<n>"` or `len:"<n>"` (starting at nextPos).
+func parseLayoutTags(t reflect.StructTag, nextPos int) (int, int, error) {
+	rangeTag := t.Get("range")
+	posTag := t.Get("pos")
+	lenTag := t.Get("len")
+
+	if rangeTag != "" {
+		if posTag != "" || lenTag != "" {
+			return 0, 0, ErrTagConflictingLayout
+		}
+		return parseRangeTag(rangeTag)
+	}
+
+	length := -1
+	if lenTag != "" {
+		var err error
+		length, err = parseLenTag(lenTag)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if posTag != "" {
+		return parsePosTag(posTag, length)
+	}
+
+	if length < 0 {
+		return 0, 0, ErrTagEmpty
+	}
+
+	return nextPos, nextPos + length, nil
+}
+
+func parseLenTag(tag string) (int, error) {
+	length, err := strconv.Atoi(tag)
+	if err != nil {
+		return 0, errors.Join(ErrTagInvalidRangeValues, err)
+	}
+	if length <= 0 {
+		return 0, ErrTagInefectualRange
+	}
+
+	return length, nil
+}
+
+// parsePosTag converts 1-based inclusive positions to a range.
+// With a single position the length must be given by the len tag.
+func parsePosTag(tag string, length int) (int, int, error) {
+	first, last, hasLast := strings.Cut(tag, ",")
+	x, err := strconv.Atoi(first)
+	if err != nil {
+		return 0, 0, errors.Join(ErrTagInvalidRangeValues, err)
+	}
+	if x < 1 {
+		return 0, 0, fmt.Errorf("%w: positions start at 1", ErrTagInvalidRangeValues)
+	}
+
+	if !hasLast {
+		if length < 0 {
+			return 0, 0, fmt.Errorf("%w: pos needs a last position or a len tag", ErrTagInvalidRangeValues)
+		}
+		return x - 1, x - 1 + length, nil
+	}
+
+	y, err := strconv.Atoi(last)
+	if err != nil {
+		return 0, 0, errors.Join(ErrTagInvalidRangeValues, err)
+	}
+	if y < x {
+		return 0, 0, ErrTagInefectualRange
+	}
+	if length >= 0 && length != y-x+1 {
+		return 0, 0, ErrTagConflictingLayout
+	}
+
+	return x - 1, y, nil
+}
+
 func parseFlagsTag(tag string) (flags, error) {
 	f := flags{}
 	if tag == "" {
@@ -97,6 +277,10 @@ func parseFlagsTag(tag string) (flags, error) {
 		switch part {
 		case "optional":
 			f.optional = true
+		case "omitzero":
+			f.omitzero = true
+		case "filler":
+			f.filler = true
 		}
 	}
 
@@ -116,6 +300,49 @@ func parseDecimalsTag(tag string) (int, error) {
 	return int(decimals), nil
 }
 
+// parseFillTag parses the filler character of a field.
+// It is either a single character (`fill:"*"`) or a hex byte (`fill:"0x00"`).
+func parseFillTag(tag string, ok bool) (int, error) {
+	if !ok {
+		return -1, nil // -1 means codec default
+	}
+
+	if len(tag) == 1 {
+		return int(tag[0]), nil
+	}
+
+	if strings.HasPrefix(tag, "0x") || strings.HasPrefix(tag, "0X") {
+		b, err := strconv.ParseUint(tag[2:], 16, 8)
+		if err == nil {
+			return int(b), nil
+		}
+	}
+
+	return -1, fmt.Errorf("invalid fill tag: %s", tag)
+}
+
+// parseTrimTag parses `trim:"<mode>"` or `trim:"<mode>:<cutset>"`,
+// where mode is one of none, left, right and both.
+func parseTrimTag(tag string) (TrimType, string, error) {
+	if tag == "" {
+		return TrimTypeDefault, "", nil
+	}
+
+	mode, cutset, _ := strings.Cut(tag, ":")
+	switch mode {
+	case "none":
+		return TrimTypeNone, cutset, nil
+	case "left":
+		return TrimTypeLeft, cutset, nil
+	case "right":
+		return TrimTypeRight, cutset, nil
+	case "both":
+		return TrimTypeBoth, cutset, nil
+	}
+
+	return TrimTypeDefault, "", fmt.Errorf("invalid trim type: %s", tag)
+}
+
 func parseAlignTag(tag string) (AlignmentType, error) {
 	if tag == "" {
 		return AlignmentTypeNone, nil
@@ -126,6 +353,8 @@ func parseAlignTag(tag string) (AlignmentType, error) {
 		return AlignmentTypeLeft, nil
 	case "right":
 		return AlignmentTypeRight, nil
+	case "center":
+		return AlignmentTypeCenter, nil
 	}
 
 	return AlignmentTypeNone, fmt.Errorf("invalid align type: %s", tag)
@@ -151,3 +380,62 @@ func parseRangeTag(tag string) (int, int, error) {
 
 	return x, y, nil
 }
+
+// recordTag holds the record level settings declared on a marker field,
+// e.g. `_ struct{} record:"length=120,prefix=0"`.
+type recordTag struct {
+	length int // -1 means not declared
+	prefix int // -1 means not declared
+}
+
+// isRecordMarker reports whether the field only carries the record tag.
+func isRecordMarker(f reflect.StructField) bool {
+	_, ok := f.Tag.Lookup("record")
+	return ok
+}
+
+// parseStructRecordTag finds and parses the record marker of a struct type.
+func parseStructRecordTag(st reflect.Type) (recordTag, error) {
+	for i := 0; i < st.NumField(); i++ {
+		if recTag, ok := st.Field(i).Tag.Lookup("record"); ok {
+			rec, err := parseRecordTag(recTag)
+			if err != nil {
+				return rec, fmt.Errorf("failed to parse record tag %s : %w", st.Field(i).Name, err)
+			}
+			return rec, nil
+		}
+	}
+
+	return recordTag{length: -1, prefix: -1}, nil
+}
+
+func parseRecordTag(tag string) (recordTag, error) {
+	res := recordTag{length: -1, prefix: -1}
+	if tag == "" {
+		return res, nil
+	}
+
+	parts := strings.Split(tag, ",")
+	for _, part := range parts {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return res, fmt.Errorf("invalid record tag: %s", tag)
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return res, fmt.Errorf("invalid record tag value %s: %s", key, value)
+		}
+
+		switch key {
+		case "length":
+			res.length = n
+		case "prefix":
+			res.prefix = n
+		default:
+			return res, fmt.Errorf("unknown record tag option: %s", key)
+		}
+	}
+
+	return res, nil
+}
@@ -158,3 +158,113 @@ func TestTag_Validate(t *testing.T) {
 	})
 
 }
+
+func TestParseRecordTag(t *testing.T) {
+	rec, err := parseRecordTag("length=120,prefix=0")
+	require.NoError(t, err)
+	require.Equal(t, 120, rec.length)
+	require.Equal(t, 0, rec.prefix)
+
+	rec, err = parseRecordTag("")
+	require.NoError(t, err)
+	require.Equal(t, -1, rec.length)
+	require.Equal(t, -1, rec.prefix)
+
+	_, err = parseRecordTag("width=10")
+	require.Error(t, err)
+
+	_, err = parseRecordTag("length")
+	require.Error(t, err)
+}
+
+func TestParseFillTag(t *testing.T) {
+	tag, err := parseFieldTag(reflect.StructTag(`range:"0,5" fill:"*"`))
+	require.NoError(t, err)
+	require.Equal(t, int('*'), tag.fill)
+
+	tag, err = parseFieldTag(reflect.StructTag(`range:"0,5" fill:"0x00"`))
+	require.NoError(t, err)
+	require.Equal(t, 0, tag.fill)
+
+	tag, err = parseFieldTag(reflect.StructTag(`range:"0,5"`))
+	require.NoError(t, err)
+	require.Equal(t, -1, tag.fill)
+
+	_, err = parseFieldTag(reflect.StructTag(`range:"0,5" fill:"ab"`))
+	require.Error(t, err)
+}
+
+func TestParseTrimTag(t *testing.T) {
+	tag, err := parseFieldTag(reflect.StructTag(`range:"0,5" trim:"left"`))
+	require.NoError(t, err)
+	require.Equal(t, TrimTypeLeft, tag.trim)
+	require.Equal(t, "", tag.cutset)
+
+	tag, err = parseFieldTag(reflect.StructTag(`range:"0,5" trim:"both:*#"`))
+	require.NoError(t, err)
+	require.Equal(t, TrimTypeBoth, tag.trim)
+	require.Equal(t, "*#", tag.cutset)
+
+	_, err = parseFieldTag(reflect.StructTag(`range:"0,5" trim:"middle"`))
+	require.Error(t, err)
+}
+
+func TestParseAlignTag(t *testing.T) {
+	for tag, want := range map[string]AlignmentType{
+		"":       AlignmentTypeNone,
+		"left":   AlignmentTypeLeft,
+		"right":  AlignmentTypeRight,
+		"center": AlignmentTypeCenter,
+	} {
+		got, err := parseAlignTag(tag)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	}
+
+	_, err := parseAlignTag("justify")
+	require.Error(t, err)
+}
+
+func TestParseLayoutTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		tag      string
+		nextPos  int
+		wantFrom int
+		wantTo   int
+		wantErr  error
+	}{
+		{name: "range", tag: `range:"3,8"`, wantFrom: 3, wantTo: 8},
+		{name: "pos", tag: `pos:"1,10"`, wantFrom: 0, wantTo: 10},
+		{name: "pos with len", tag: `pos:"11" len:"5"`, wantFrom: 10, wantTo: 15},
+		{name: "len", tag: `len:"4"`, nextPos: 6, wantFrom: 6, wantTo: 10},
+		{name: "range and len", tag: `range:"0,4" len:"4"`, wantErr: ErrTagConflictingLayout},
+		{name: "pos and wrong len", tag: `pos:"1,4" len:"3"`, wantErr: ErrTagConflictingLayout},
+		{name: "pos zero", tag: `pos:"0,4"`, wantErr: ErrTagInvalidRangeValues},
+		{name: "pos without end", tag: `pos:"3"`, wantErr: ErrTagInvalidRangeValues},
+		{name: "zero len", tag: `len:"0"`, wantErr: ErrTagInefectualRange},
+		{name: "no layout", tag: `align:"left"`, wantErr: ErrTagEmpty},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := parseLayoutTags(reflect.StructTag(tt.tag), tt.nextPos)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantFrom, from)
+			require.Equal(t, tt.wantTo, to)
+		})
+	}
+}
+
+func TestParseCharsetTag(t *testing.T) {
+	tag, err := parseFieldTag(reflect.StructTag(`range:"0,5" charset:"shift-jis"`))
+	require.NoError(t, err)
+	require.Equal(t, CharsetShiftJIS, tag.charset)
+
+	_, err = parseFieldTag(reflect.StructTag(`range:"0,5" charset:"klingon"`))
+	require.Error(t, err)
+}
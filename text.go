@@ -0,0 +1,173 @@
+package fixedlength
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+type NormalizationType int
+
+var (
+	NormalizationTypeNone NormalizationType = 0
+	NormalizationTypeNFC  NormalizationType = 1
+	NormalizationTypeNFD  NormalizationType = 2
+)
+
+type CaseType int
+
+var (
+	CaseTypeNone  CaseType = 0
+	CaseTypeUpper CaseType = 1
+	CaseTypeLower CaseType = 2
+)
+
+// TextTransform prepares string fields on encode, before they are padded.
+// Steps run in order: ASCII transliteration (of the composed text), case folding,
+// normalization, stripping.
+type TextTransform struct {
+	Normalization NormalizationType
+	// ASCII transliterates to ASCII: "Müller" becomes "Mueller", "José" becomes "Jose".
+	ASCII bool
+	Case  CaseType
+	// Allowed lists the allowed characters as the content of a regexp character class,
+	// e.g. "A-Z0-9 .-". Other characters are removed. Empty allows everything.
+	Allowed string
+}
+
+// transliterations are the ASCII replacements that don't follow from dropping accents.
+var transliterations = map[rune]string{
+	'Ä': "Ae", 'Ö': "Oe", 'Ü': "Ue", 'ä': "ae", 'ö': "oe", 'ü': "ue", 'ß': "ss", 'ẞ': "SS",
+	'Æ': "AE", 'æ': "ae", 'Œ': "OE", 'œ': "oe", 'Ø': "O", 'ø': "o", 'Å': "A", 'å': "a",
+	'Þ': "TH", 'þ': "th", 'Ð': "D", 'ð': "d", 'Đ': "D", 'đ': "d", 'Ł': "L", 'ł': "l",
+	'Ħ': "H", 'ħ': "h", 'ı': "i", 'Ĳ': "IJ", 'ĳ': "ij", 'Ŀ': "L", 'ŀ': "l", 'ŉ': "'n",
+	'Ŧ': "T", 'ŧ': "t", 'ſ': "s", 'ƒ': "f",
+	'‘': "'", '’': "'", '‚': "'", '“': "\"", '”': "\"", '„': "\"", '–': "-", '—': "-",
+	'…': "...", '€': "EUR", '£': "GBP", '¥': "JPY", '©': "(C)", '®': "(R)", '°': "o",
+	'«': "<<", '»': ">>", '¿': "?", '¡': "!", '×': "x", '÷': "/", ' ': " ",
+}
+
+// Apply runs the transform on str.
+func (tt TextTransform) Apply(str string) (string, error) {
+	if tt.ASCII {
+		str = ToASCII(str)
+	}
+
+	switch tt.Case {
+	case CaseTypeUpper:
+		str = strings.ToUpper(str)
+	case CaseTypeLower:
+		str = strings.ToLower(str)
+	}
+
+	switch tt.Normalization {
+	case NormalizationTypeNFC:
+		str = NormalizeNFC(str)
+	case NormalizationTypeNFD:
+		str = NormalizeNFD(str)
+	}
+
+	if tt.Allowed != "" {
+		re, err := allowedRegexp(tt.Allowed)
+		if err != nil {
+			return "", err
+		}
+		str = re.ReplaceAllString(str, "")
+	}
+
+	return str, nil
+}
+
+var allowedCache sync.Map // allowed character class to *regexp.Regexp of the others
+
+func allowedRegexp(allowed string) (*regexp.Regexp, error) {
+	if re, ok := allowedCache.Load(allowed); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile("[^" + allowed + "]")
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowed characters %s: %w", allowed, err)
+	}
+	allowedCache.Store(allowed, re)
+	return re, nil
+}
+
+// NormalizeNFD returns the canonical decomposition (Unicode NFD) of str.
+func NormalizeNFD(str string) string {
+	return norm.NFD.String(str)
+}
+
+// NormalizeNFC returns the canonical composition (Unicode NFC) of str.
+func NormalizeNFC(str string) string {
+	return norm.NFC.String(str)
+}
+
+// ToASCII transliterates str to ASCII. Accents are dropped, letters like ä or ß are
+// spelled out, characters without a transliteration become '?'. Decomposed input is
+// composed first, so "Mu\u0308ller" becomes "Mueller" like "Müller".
+func ToASCII(str string) string {
+	var sb strings.Builder
+	for _, r := range NormalizeNFC(str) {
+		if r < 0x80 {
+			sb.WriteRune(r)
+			continue
+		}
+		if t, ok := transliterations[r]; ok {
+			sb.WriteString(t)
+			continue
+		}
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if base := []rune(NormalizeNFD(string(r))); len(base) > 1 && base[0] < 0x80 {
+			sb.WriteRune(base[0])
+			continue
+		}
+		sb.WriteRune('?')
+	}
+	return sb.String()
+}
+
+// parseTransformTag parses `transform:"<step>,..."` with the steps
+// ascii, upper, lower, nfc and nfd, or none to switch the codec transform off.
+func parseTransformTag(tag string) (TextTransform, error) {
+	tt := TextTransform{}
+	for _, part := range strings.Split(tag, ",") {
+		switch part {
+		case "none":
+		case "ascii":
+			tt.ASCII = true
+		case "upper":
+			tt.Case = CaseTypeUpper
+		case "lower":
+			tt.Case = CaseTypeLower
+		case "nfc":
+			tt.Normalization = NormalizationTypeNFC
+		case "nfd":
+			tt.Normalization = NormalizationTypeNFD
+		default:
+			return tt, fmt.Errorf("invalid transform: %s", part)
+		}
+	}
+	return tt, nil
+}
+
+// fieldTransform returns the transform of a string field: the codec one,
+// replaced by the transform tag and with the allowed characters of the allow tag.
+func (c *Codec) fieldTransform(t tag) TextTransform {
+	tt := c.config.Transform
+	if t.transform != nil {
+		allowed := tt.Allowed
+		tt = *t.transform
+		tt.Allowed = allowed
+	}
+	if t.allowed != nil {
+		tt.Allowed = *t.allowed
+	}
+	return tt
+}
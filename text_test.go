@@ -0,0 +1,72 @@
+package fixedlength
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToASCII(t *testing.T) {
+	tests := map[string]string{
+		"Müller":       "Mueller",
+		"José":         "Jose",
+		"Straße":       "Strasse",
+		"Łódź":         "Lodz",
+		"Ærøskøbing":   "AEroskobing",
+		"Jose\u0301":   "Jose",
+		"Mu\u0308ller": "Mueller",
+		"Nguyễn":       "Nguyen",
+		"日本":           "??",
+		"plain ascii!": "plain ascii!",
+	}
+
+	for in, want := range tests {
+		require.Equal(t, want, ToASCII(in), in)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	require.Equal(t, "e\u0323\u0302", NormalizeNFD("\u1EC7"))
+	require.Equal(t, "\u1EC7", NormalizeNFC("e\u0323\u0302"))
+	require.Equal(t, "Jos\u00E9", NormalizeNFC("Jose\u0301"))
+	require.Equal(t, "日本", NormalizeNFC("日本"))
+
+	// combining marks are put in canonical order
+	require.Equal(t, "\u1EC7", NormalizeNFC("e\u0302\u0323"))
+	require.Equal(t, "e\u0323\u0302", NormalizeNFD("e\u0302\u0323"))
+	require.Equal(t, "\uAC00", NormalizeNFC("\u1100\u1161"))
+}
+
+func TestTextTransform(t *testing.T) {
+	tt := TextTransform{ASCII: true, Case: CaseTypeUpper, Allowed: "A-Z0-9 "}
+	res, err := tt.Apply("Müller-Lüdenscheidt, José")
+	require.NoError(t, err)
+	require.Equal(t, "MUELLERLUEDENSCHEIDT JOSE", res)
+
+	res, err = TextTransform{ASCII: true}.Apply("Mu\u0308ller")
+	require.NoError(t, err)
+	require.Equal(t, "Mueller", res)
+
+	_, err = TextTransform{Allowed: "z-a"}.Apply("abc")
+	require.Error(t, err)
+}
+
+func TestMarshalTransform(t *testing.T) {
+	type rec struct {
+		Name    string `range:"0,10"`
+		Comment string `range:"10,16" transform:"none" allow:"a-z"`
+		Raw     string `range:"16,20" transform:"none"`
+	}
+
+	cfg := DefaultConfig()
+	cfg.TypePrefixLength = 0
+	cfg.Transform = TextTransform{ASCII: true, Case: CaseTypeUpper}
+	c := NewCodec(cfg)
+
+	res, err := c.Marshal(&rec{Name: "Müller", Comment: "ok, ä!", Raw: "Jö"})
+	require.NoError(t, err)
+	require.Equal(t, "MUELLER   ok    Jö  ", string(res))
+
+	_, err = parseFieldTag(`range:"0,5" transform:"title"`)
+	require.Error(t, err)
+}
@@ -0,0 +1,37 @@
+package fixedlength
+
+import (
+	"reflect"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// isFormattedTime reports whether field is a time.Time laid out by a
+// `format:"<layout>"` tag, like `format:"20060102"`, rather than by its text marshaling.
+func isFormattedTime(field reflect.Value, t tag) bool {
+	return t.timeFormat != "" && field.Type() == timeType && field.CanInterface()
+}
+
+// formatTime returns the time in field in the layout of t, zero times are blank.
+func formatTime(field reflect.Value, t tag) string {
+	tm := field.Interface().(time.Time)
+	if tm.IsZero() {
+		return ""
+	}
+	return tm.Format(t.timeFormat)
+}
+
+// parseTime sets field from a value in the layout of t, blank values to the zero time.
+func parseTime(field reflect.Value, value string, t tag) error {
+	if value == "" {
+		field.Set(reflect.Zero(timeType))
+		return nil
+	}
+	tm, err := time.Parse(t.timeFormat, value)
+	if err != nil {
+		return err
+	}
+	field.Set(reflect.ValueOf(tm))
+	return nil
+}
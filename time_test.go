@@ -0,0 +1,29 @@
+package fixedlength
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type timeRecord struct {
+	_      struct{}  `record:"prefix=0"`
+	Booked time.Time `range:"0,8" format:"20060102"`
+	Valued time.Time `range:"8,14" format:"060102"`
+	Code   string    `range:"14,16"`
+}
+
+func TestTimeFormat(t *testing.T) {
+	in := timeRecord{Booked: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), Code: "OK"}
+	res, err := Marshal(&in)
+	require.NoError(t, err)
+	require.Equal(t, "20240229      OK", string(res))
+
+	var out timeRecord
+	require.NoError(t, Unmarshal(res, &out))
+	require.Equal(t, in, out)
+
+	err = Unmarshal([]byte("20240230240101OK"), &out)
+	require.Error(t, err)
+}
@@ -0,0 +1,286 @@
+package fixedlength
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidPackedDecimal = errors.New("fixedlength: invalid packed decimal")
+
+// Usage is how a number is stored, after the COBOL USAGE clause.
+type Usage int
+
+var (
+	UsageDisplay Usage = 0 // digits as text
+	UsageBinary  Usage = 1 // big endian two's complement, COMP, COMP-4, COMP-5 and BINARY
+	UsagePacked  Usage = 2 // packed decimal, two digits per byte and a sign nibble, COMP-3
+)
+
+// Sign is how the sign of a number is stored, after the COBOL SIGN clause.
+type Sign int
+
+var (
+	SignOverpunch Sign = 0 // over the last digit of display numbers, in the sign nibble of packed decimals
+	SignLeading   Sign = 1 // separate leading + or - character, SIGN LEADING SEPARATE
+	SignTrailing  Sign = 2 // separate trailing + or - character, SIGN TRAILING SEPARATE
+	SignNone      Sign = 3 // unsigned, packed decimals take the F sign nibble
+)
+
+// parseSignTag parses `sign:"leading|trailing|none"`.
+func parseSignTag(tag string) (Sign, error) {
+	switch strings.ToLower(tag) {
+	case "", "overpunch":
+		return SignOverpunch, nil
+	case "leading":
+		return SignLeading, nil
+	case "trailing":
+		return SignTrailing, nil
+	case "none", "unsigned":
+		return SignNone, nil
+	}
+	return SignOverpunch, fmt.Errorf("invalid sign: %s", tag)
+}
+
+// separate reports whether the sign takes a character of its own.
+func (s Sign) separate() bool {
+	return s == SignLeading || s == SignTrailing
+}
+
+// parseUsageTag parses `usage:"display|comp|comp-3"` and the COBOL synonyms.
+func parseUsageTag(tag string) (Usage, error) {
+	switch strings.ToLower(tag) {
+	case "", "display":
+		return UsageDisplay, nil
+	case "comp", "comp-4", "comp-5", "binary", "computational", "computational-4", "computational-5":
+		return UsageBinary, nil
+	case "comp-3", "packed-decimal", "computational-3":
+		return UsagePacked, nil
+	}
+	return UsageDisplay, fmt.Errorf("invalid usage: %s", tag)
+}
+
+// BinaryLength returns the bytes taken by a binary number of digits digits.
+func BinaryLength(digits int) int {
+	switch {
+	case digits <= 4:
+		return 2
+	case digits <= 9:
+		return 4
+	}
+	return 8
+}
+
+// PackedLength returns the bytes taken by a packed decimal of digits digits.
+func PackedLength(digits int) int {
+	return digits/2 + 1
+}
+
+// encodeUsage stores a number converted by ConvertAsciiToEBCDICNumber in the
+// usage of t, over exactly the length of t.
+func encodeUsage(num string, t tag) (string, error) {
+	ascii, err := ConvertEBCDICToAsciiNumber(num, 0)
+	if err != nil {
+		return "", err
+	}
+
+	if t.sign == SignNone && strings.HasPrefix(ascii, "-") {
+		return "", fmt.Errorf("unsigned number %s is negative", ascii)
+	}
+
+	switch t.usage {
+	case UsagePacked:
+		return packDecimal(ascii, t.Len(), t.sign == SignNone)
+	case UsageBinary:
+		return encodeBinary(ascii, t.Len())
+	}
+	return "", fmt.Errorf("invalid usage: %d", t.usage)
+}
+
+// decodeUsage reads a number stored in the usage of t, in the form
+// ConvertEBCDICToAsciiNumber expects.
+func decodeUsage(data []byte, t tag) (string, error) {
+	var ascii string
+	var err error
+	switch t.usage {
+	case UsagePacked:
+		ascii, err = unpackDecimal(data)
+	case UsageBinary:
+		ascii, err = decodeBinary(data)
+	default:
+		err = fmt.Errorf("invalid usage: %d", t.usage)
+	}
+	if err != nil {
+		return "", err
+	}
+	return ConvertAsciiToEBCDICNumber(ascii, 0)
+}
+
+// encodeSign stores the sign of a display number converted by ConvertAsciiToEBCDICNumber
+// as t.sign asks. Separate signs take the whole length of t, the digits are zero filled.
+func encodeSign(num string, t tag) (string, error) {
+	if t.sign == SignOverpunch {
+		return num, nil
+	}
+
+	ascii, err := ConvertEBCDICToAsciiNumber(num, 0)
+	if err != nil {
+		return "", err
+	}
+	digits, negative := strings.CutPrefix(ascii, "-")
+	if t.sign == SignNone {
+		if negative {
+			return "", fmt.Errorf("unsigned number %s is negative", ascii)
+		}
+		return num, nil
+	}
+
+	width := t.Len() - 1
+	if len(digits) > width {
+		return "", fmt.Errorf("number %s has more than %d digits", ascii, width)
+	}
+	digits = strings.Repeat("0", width-len(digits)) + digits
+
+	sign := "+"
+	if negative {
+		sign = "-"
+	}
+	if t.sign == SignLeading {
+		return sign + digits, nil
+	}
+	return digits + sign, nil
+}
+
+// decodeSign moves the separate sign of a display number over its last digit, in the
+// form ConvertEBCDICToAsciiNumber expects.
+func decodeSign(value string, t tag) (string, error) {
+	if t.usage != UsageDisplay || !t.sign.separate() || value == "" {
+		return value, nil
+	}
+
+	var sign byte
+	digits := value
+	if t.sign == SignLeading {
+		sign, digits = value[0], value[1:]
+	} else {
+		sign, digits = value[len(value)-1], value[:len(value)-1]
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return "", fmt.Errorf("invalid number: %s", value)
+		}
+	}
+
+	switch sign {
+	case '+':
+		return digits, nil
+	case '-':
+		return ConvertAsciiToEBCDICNumber("-"+digits, 0)
+	}
+	return "", fmt.Errorf("invalid sign in %s", value)
+}
+
+// packDecimal packs an integer like "-12345" into length bytes, unsigned numbers
+// with the F sign nibble.
+func packDecimal(num string, length int, unsigned bool) (string, error) {
+	sign := byte(0x0C)
+	if unsigned {
+		sign = 0x0F
+	}
+	digits := num
+	if strings.HasPrefix(digits, "-") {
+		sign = 0x0D
+		digits = digits[1:]
+	}
+	if capacity := 2*length - 1; len(digits) > capacity {
+		return "", fmt.Errorf("number %s has more than %d digits", num, capacity)
+	}
+
+	nibbles := strings.Repeat("0", 2*length-1-len(digits)) + digits
+	res := make([]byte, length)
+	for i := 0; i < len(nibbles); i++ {
+		d := nibbles[i] - '0'
+		if d > 9 {
+			return "", fmt.Errorf("invalid number: %s", num)
+		}
+		if i%2 == 0 {
+			res[i/2] = d << 4
+		} else {
+			res[i/2] |= d
+		}
+	}
+	res[length-1] |= sign
+	return string(res), nil
+}
+
+// unpackDecimal returns the integer packed in data, like "-12345".
+func unpackDecimal(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", fmt.Errorf("%w: no data", ErrInvalidPackedDecimal)
+	}
+
+	var sb strings.Builder
+	for i, b := range data {
+		hi, lo := b>>4, b&0x0F
+		if hi > 9 {
+			return "", fmt.Errorf("%w: % X", ErrInvalidPackedDecimal, data)
+		}
+		sb.WriteByte('0' + hi)
+
+		if i < len(data)-1 {
+			if lo > 9 {
+				return "", fmt.Errorf("%w: % X", ErrInvalidPackedDecimal, data)
+			}
+			sb.WriteByte('0' + lo)
+			continue
+		}
+
+		switch lo {
+		case 0x0B, 0x0D:
+			return "-" + sb.String(), nil
+		case 0x0A, 0x0C, 0x0E, 0x0F:
+		default:
+			return "", fmt.Errorf("%w: sign %X", ErrInvalidPackedDecimal, lo)
+		}
+	}
+	return sb.String(), nil
+}
+
+// encodeBinary stores an integer as a big endian signed number of length bytes.
+func encodeBinary(num string, length int) (string, error) {
+	if length < 1 || length > 8 {
+		return "", fmt.Errorf("invalid binary length: %d", length)
+	}
+	v, err := strconv.ParseInt(num, 10, 64)
+	if err != nil {
+		return "", err
+	}
+	if length < 8 {
+		limit := int64(1) << (8*length - 1)
+		if v < -limit || v >= limit {
+			return "", fmt.Errorf("number %s does not fit in %d bytes", num, length)
+		}
+	}
+
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(v))
+	return string(buf[8-length:]), nil
+}
+
+// decodeBinary returns the big endian signed number in data.
+func decodeBinary(data []byte) (string, error) {
+	if len(data) == 0 || len(data) > 8 {
+		return "", fmt.Errorf("invalid binary length: %d", len(data))
+	}
+
+	var buf [8]byte
+	if data[0]&0x80 != 0 {
+		for i := range buf {
+			buf[i] = 0xFF
+		}
+	}
+	copy(buf[8-len(data):], data)
+	return strconv.FormatInt(int64(binary.BigEndian.Uint64(buf[:])), 10), nil
+}
@@ -0,0 +1,88 @@
+package fixedlength
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type usageRecord struct {
+	_      struct{} `record:"prefix=0"`
+	Code   string   `range:"0,2"`
+	Amount float64  `range:"2,5" usage:"comp-3" decimals:"2"`
+	Count  int      `range:"5,7" usage:"comp"`
+	Total  int64    `range:"7,11" usage:"binary"`
+}
+
+func TestUsageRoundTrip(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.PositionUnit = PositionUnitByte
+	codec := NewCodec(cfg)
+
+	in := usageRecord{Code: "AB", Amount: -123.45, Count: 300, Total: -2}
+	res, err := codec.Marshal(&in)
+	require.NoError(t, err)
+	require.Equal(t, []byte{'A', 'B', 0x12, 0x34, 0x5D, 0x01, 0x2C, 0xFF, 0xFF, 0xFF, 0xFE}, res)
+
+	var out usageRecord
+	require.NoError(t, codec.Unmarshal(res, &out))
+	require.Equal(t, in, out)
+}
+
+func TestUsageErrors(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.PositionUnit = PositionUnitByte
+	codec := NewCodec(cfg)
+
+	var out usageRecord
+	err := codec.Unmarshal([]byte{'A', 'B', 0x12, 0x34, 0x57, 0, 1, 0, 0, 0, 1}, &out)
+	require.ErrorIs(t, err, ErrInvalidPackedDecimal)
+
+	_, err = codec.Marshal(&usageRecord{Count: 40000})
+	require.Error(t, err)
+
+	// binary usages count bytes
+	err = Unmarshal([]byte("AB         "), &out)
+	require.ErrorContains(t, err, "binary usage requires byte positions")
+
+	// at most 8 bytes fit a binary number
+	type wide struct {
+		N int64 `range:"0,10" usage:"comp"`
+	}
+	_, err = codec.Marshal(&wide{N: 1})
+	require.ErrorContains(t, err, "binary usage takes 1 to 8 bytes, not 10")
+	err = codec.Unmarshal(make([]byte, 10), &wide{})
+	require.ErrorContains(t, err, "binary usage takes 1 to 8 bytes, not 10")
+}
+
+func TestSign(t *testing.T) {
+	type rec struct {
+		_       struct{} `record:"prefix=0"`
+		Leading int      `range:"0,4" sign:"leading"`
+		Amount  float64  `range:"4,9" sign:"trailing" decimals:"2"`
+		Count   int      `range:"9,11" sign:"none"`
+	}
+
+	in := rec{Leading: 12, Amount: -1.5, Count: 7}
+	res, err := Marshal(&in)
+	require.NoError(t, err)
+	require.Equal(t, "+0120150-07", string(res))
+
+	var out rec
+	require.NoError(t, Unmarshal(res, &out))
+	require.Equal(t, in, out)
+
+	_, err = Marshal(&rec{Count: -7})
+	require.ErrorContains(t, err, "unsigned number -7 is negative")
+	_, err = Marshal(&rec{Leading: 1000})
+	require.ErrorContains(t, err, "number 1000 has more than 3 digits")
+	require.Error(t, Unmarshal([]byte("*0120150-07"), &out))
+}
+
+func TestUsageLengths(t *testing.T) {
+	require.Equal(t, 2, BinaryLength(4))
+	require.Equal(t, 4, BinaryLength(9))
+	require.Equal(t, 8, BinaryLength(18))
+	require.Equal(t, 3, PackedLength(5))
+	require.Equal(t, 4, PackedLength(6))
+}
@@ -2,6 +2,8 @@ package fixedlength
 
 import (
 	"fmt"
+	"strings"
+	"unicode"
 )
 
 type AlignmentType int
@@ -13,10 +15,58 @@ var (
 	AlignmentTypeCenter AlignmentType = 3
 )
 
+type TrimType int
+
+var (
+	TrimTypeDefault TrimType = 0 // derived from the field alignment
+	TrimTypeNone    TrimType = 1
+	TrimTypeLeft    TrimType = 2
+	TrimTypeRight   TrimType = 3
+	TrimTypeBoth    TrimType = 4
+)
+
+// TrimString removes the characters in cutset from the sides selected by trimType.
+// An empty cutset trims white space.
+func TrimString(str string, trimType TrimType, cutset string) string {
+	isCut := unicode.IsSpace
+	if cutset != "" {
+		isCut = func(r rune) bool { return strings.ContainsRune(cutset, r) }
+	}
+
+	switch trimType {
+	case TrimTypeLeft:
+		return strings.TrimLeftFunc(str, isCut)
+	case TrimTypeRight:
+		return strings.TrimRightFunc(str, isCut)
+	case TrimTypeBoth, TrimTypeDefault:
+		return strings.TrimFunc(str, isCut)
+	}
+
+	return str
+}
+
+// trimTypeForAlignment returns the sides where formatWithFiller puts the padding.
+func trimTypeForAlignment(alignmentType AlignmentType) TrimType {
+	switch alignmentType {
+	case AlignmentTypeLeft:
+		return TrimTypeRight
+	case AlignmentTypeRight:
+		return TrimTypeLeft
+	}
+	return TrimTypeBoth
+}
+
+// FormatStringWithAlignment pads str with spaces to length runes. Text with full width
+// characters is padded to display columns by FormatStringWithDisplayWidth.
 func FormatStringWithAlignment(str string, length int, alignmentType AlignmentType) (string, error) {
 	return formatWithFiller(str, length, alignmentType, ' ')
 }
 
+// FormatStringWithFiller pads str to length with the given filler character.
+func FormatStringWithFiller(str string, length int, alignmentType AlignmentType, filler byte) (string, error) {
+	return formatWithFiller(str, length, alignmentType, filler)
+}
+
 func FormatStrNumberWithAlignment(strNum string, length int, leadingZeroes bool, alignmentType AlignmentType) (string, error) {
 	filler := byte(' ')
 	if leadingZeroes {
@@ -75,3 +125,25 @@ func formatWithFiller(str string, length int, alignmentType AlignmentType, fille
 
 	return string(result), nil
 }
+
+// formatWithWidth pads str, which takes width units, to length units.
+// filler must take exactly one unit.
+func formatWithWidth(str string, width int, length int, alignmentType AlignmentType, filler string) (string, error) {
+	if width > length {
+		return "", fmt.Errorf("string %s length %d exceeds target length %d", str, width, length)
+	}
+
+	diff := length - width
+	leftPad := 0
+	switch alignmentType {
+	case AlignmentTypeLeft:
+	case AlignmentTypeRight:
+		leftPad = diff
+	case AlignmentTypeCenter:
+		leftPad = diff / 2
+	default:
+		return "", fmt.Errorf("unsupported alignment type")
+	}
+
+	return strings.Repeat(filler, leftPad) + str + strings.Repeat(filler, diff-leftPad), nil
+}
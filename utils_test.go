@@ -164,3 +164,44 @@ func TestFormatIntWithPadding(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatStringWithFiller(t *testing.T) {
+	res, err := FormatStringWithFiller("123", 6, AlignmentTypeRight, '*')
+	assert.NoError(t, err)
+	assert.Equal(t, "***123", res)
+
+	res, err = FormatStringWithFiller("ab", 4, AlignmentTypeLeft, 0x00)
+	assert.NoError(t, err)
+	assert.Equal(t, "ab\x00\x00", res)
+}
+
+func TestTrimString(t *testing.T) {
+	tests := []struct {
+		name     string
+		str      string
+		trimType TrimType
+		cutset   string
+		wantStr  string
+	}{
+		{name: "none", str: "  ab  ", trimType: TrimTypeNone, wantStr: "  ab  "},
+		{name: "left", str: "  ab  ", trimType: TrimTypeLeft, wantStr: "ab  "},
+		{name: "right", str: "  ab  ", trimType: TrimTypeRight, wantStr: "  ab"},
+		{name: "both", str: "  ab  ", trimType: TrimTypeBoth, wantStr: "ab"},
+		{name: "custom cutset", str: "*#ab# ", trimType: TrimTypeBoth, cutset: "*#", wantStr: "ab# "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantStr, TrimString(tt.str, tt.trimType, tt.cutset))
+		})
+	}
+}
+
+func TestFormatWithWidth(t *testing.T) {
+	res, err := formatWithWidth("ü", 2, 5, AlignmentTypeCenter, "-")
+	assert.NoError(t, err)
+	assert.Equal(t, "-ü--", res)
+
+	_, err = formatWithWidth("abc", 3, 2, AlignmentTypeLeft, " ")
+	assert.Error(t, err)
+}
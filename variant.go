@@ -0,0 +1,189 @@
+package fixedlength
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrUnknownVariant       = errors.New("fixedlength: unknown variant")
+	ErrInvalidDiscriminator = errors.New("fixedlength: invalid discriminator")
+)
+
+var (
+	variantsMu sync.RWMutex
+	variants   = map[reflect.Type]map[string]reflect.Type{}
+)
+
+// RegisterVariants registers the concrete types an interface field of type I holds,
+// by discriminator code. The values are prototypes, only their type is used:
+// a struct registers values, a pointer to a struct (nil is fine) registers pointers.
+//
+// An interface field tagged with `discriminator:"<field>"` is decoded as the variant
+// registered for the value of that field, which must be declared before it in the same
+// struct. The variant fields are laid out relative to the start of the interface field
+// range, and Marshal encodes the concrete value the field holds, which must be the
+// variant registered for the code. A blank code leaves the field nil, a nil field is
+// written blank.
+//
+//	RegisterVariants(map[string]PaymentDetails{"C": Card{}, "W": (*Wire)(nil)})
+//
+// RegisterVariants panics if I is not an interface or a prototype is not a struct.
+func RegisterVariants[I any](codes map[string]I) {
+	iface := reflect.TypeOf((*I)(nil)).Elem()
+	if iface.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("fixedlength: RegisterVariants of non interface type %s", iface))
+	}
+
+	variantsMu.Lock()
+	defer variantsMu.Unlock()
+	registered := variants[iface]
+	if registered == nil {
+		registered = map[string]reflect.Type{}
+		variants[iface] = registered
+	}
+	for code, proto := range codes {
+		t := reflect.TypeOf(proto)
+		if t == nil || !isStructOrStructPointer(t) {
+			panic(fmt.Sprintf("fixedlength: variant %q of %s is not a struct: %v", code, iface, t))
+		}
+		registered[code] = t
+	}
+}
+
+func isStructOrStructPointer(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// variantType returns the concrete type registered for code on the interface type iface.
+func variantType(iface reflect.Type, code string) (reflect.Type, bool) {
+	variantsMu.RLock()
+	defer variantsMu.RUnlock()
+	t, ok := variants[iface][code]
+	return t, ok
+}
+
+// fieldCode returns the value of the already decoded field name of sv as a code,
+// without the padding. Discriminators and view conditions compare codes.
+func fieldCode(sv reflect.Value, name string) (string, error) {
+	f := sv.FieldByName(name)
+	if !f.IsValid() {
+		return "", fmt.Errorf("no field %s", name)
+	}
+	if f.Kind() == reflect.String {
+		return strings.TrimSpace(f.String()), nil
+	}
+	if !f.CanInterface() {
+		return "", fmt.Errorf("field %s is not exported", name)
+	}
+	return strings.TrimSpace(fmt.Sprint(f.Interface())), nil
+}
+
+// unmarshalVariant decodes the range of t into a new value of the variant selected
+// by the discriminator field of sv, and stores it in the interface field.
+func (c *Codec) unmarshalVariant(rr *recordReader, sv reflect.Value, field reflect.Value, t tag, path string) error {
+	if field.Kind() != reflect.Interface {
+		return fmt.Errorf("%w: %s is not an interface", ErrInvalidDiscriminator, field.Type())
+	}
+
+	code, err := fieldCode(sv, t.discriminator)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidDiscriminator, err)
+	}
+	if code == "" {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	vt, ok := variantType(field.Type(), code)
+	if !ok {
+		return fmt.Errorf("%w: %q for %s", ErrUnknownVariant, code, field.Type())
+	}
+
+	structType := vt
+	if vt.Kind() == reflect.Pointer {
+		structType = vt.Elem()
+	}
+	v := reflect.New(structType)
+	window, err := rr.window(t)
+	if err != nil {
+		return err
+	}
+	if _, _, err := c.unmarshalStruct(window, v.Elem(), path, 0); err != nil {
+		return err
+	}
+
+	if vt.Kind() != reflect.Pointer {
+		v = v.Elem()
+	}
+	field.Set(v)
+	return nil
+}
+
+// checkVariant verifies that the concrete value of an interface field is the variant
+// registered for the code in its discriminator field. Nil fields are written blank
+// whatever the code.
+func checkVariant(sv reflect.Value, field reflect.Value, t tag) error {
+	if field.Kind() != reflect.Interface || field.IsNil() {
+		return nil
+	}
+	v := field.Elem()
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		return nil
+	}
+
+	code, err := fieldCode(sv, t.discriminator)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidDiscriminator, err)
+	}
+	vt, ok := variantType(field.Type(), code)
+	if !ok {
+		return fmt.Errorf("%w: %q for %s", ErrUnknownVariant, code, field.Type())
+	}
+	if structOf(vt) != structOf(v.Type()) {
+		return fmt.Errorf("%w: %s holds %s, but %s %q selects %s", ErrInvalidDiscriminator, t.name, v.Type(), t.discriminator, code, vt)
+	}
+	return nil
+}
+
+func structOf(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+	return t
+}
+
+// marshalVariant encodes the concrete value of an interface field over the range of t,
+// path is the struct path of the field.
+func (c *Codec) marshalVariant(field reflect.Value, t tag, path string) (string, error) {
+	if field.Kind() != reflect.Interface {
+		return "", fmt.Errorf("%w: %s is not an interface", ErrInvalidDiscriminator, field.Type())
+	}
+
+	v := field.Elem()
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return c.fillerString(t.Len()), nil
+		}
+		v = v.Elem()
+	} else if v.IsValid() {
+		// copy to an addressable value, so pointer receiver hooks are found
+		addressable := reflect.New(v.Type()).Elem()
+		addressable.Set(v)
+		v = addressable
+	}
+
+	if !v.IsValid() {
+		return c.fillerString(t.Len()), nil
+	}
+	if v.Kind() != reflect.Struct {
+		return "", fmt.Errorf("%w: %s", ErrUnknownVariant, v.Type())
+	}
+	return c.marshalStruct(v, path, 0, t.Len())
+}
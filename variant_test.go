@@ -0,0 +1,138 @@
+package fixedlength
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type paymentDetails interface {
+	isPaymentDetails()
+}
+
+type cardDetails struct {
+	Number string `range:"0,4"`
+	Expiry string `range:"4,8"`
+}
+
+func (cardDetails) isPaymentDetails() {}
+
+type wireDetails struct {
+	IBAN string `range:"0,6"`
+	Fee  int    `range:"6,8"`
+}
+
+func (*wireDetails) isPaymentDetails() {}
+
+type variantPayment struct {
+	Method  string         `range:"2,3"`
+	Details paymentDetails `range:"3,11" discriminator:"Method"`
+	Amount  int            `range:"11,15"`
+}
+
+func init() {
+	RegisterVariants(map[string]paymentDetails{
+		"C": cardDetails{},
+		"W": (*wireDetails)(nil),
+	})
+}
+
+func TestVariants(t *testing.T) {
+	card := variantPayment{Method: "C", Details: cardDetails{Number: "4242", Expiry: "1227"}, Amount: 5}
+	res, err := Marshal(&card)
+	require.NoError(t, err)
+	require.Equal(t, "C424212270005", string(res))
+
+	var got variantPayment
+	require.NoError(t, Unmarshal(append([]byte("01"), res...), &got))
+	require.Equal(t, card, got)
+
+	wire := variantPayment{Method: "W", Details: &wireDetails{IBAN: "DE89", Fee: 3}, Amount: 12}
+	res, err = Marshal(&wire)
+	require.NoError(t, err)
+	require.Equal(t, "WDE89  030012", string(res))
+
+	got = variantPayment{}
+	require.NoError(t, Unmarshal(append([]byte("01"), res...), &got))
+	require.Equal(t, wire, got)
+}
+
+func TestVariantBlank(t *testing.T) {
+	res, err := Marshal(&variantPayment{Amount: 1})
+	require.NoError(t, err)
+	require.Equal(t, "         0001", string(res))
+
+	got := variantPayment{Details: cardDetails{}}
+	require.NoError(t, Unmarshal([]byte("01         0001"), &got))
+	require.Nil(t, got.Details)
+	require.Equal(t, 1, got.Amount)
+}
+
+func TestVariantUnknownCode(t *testing.T) {
+	var got variantPayment
+	err := Unmarshal([]byte("01X424212270005"), &got)
+	require.ErrorIs(t, err, ErrUnknownVariant)
+}
+
+func TestVariantByteWindow(t *testing.T) {
+	c := NewCodec(Config{
+		AlignmentType:            AlignmentTypeLeft,
+		NumbersWithLeadingZeroes: true,
+		TypePrefixLength:         2,
+		Filler:                   ' ',
+		PositionUnit:             PositionUnitByte,
+	})
+
+	v := variantPayment{Method: "W", Details: &wireDetails{IBAN: "DÉ89", Fee: 3}, Amount: 12}
+	res, err := c.Marshal(&v)
+	require.NoError(t, err)
+	require.Equal(t, "WDÉ89 030012", string(res))
+
+	var got variantPayment
+	require.NoError(t, c.Unmarshal(append([]byte("01"), res...), &got))
+	require.Equal(t, v, got)
+}
+
+func TestVariantMismatch(t *testing.T) {
+	_, err := Marshal(&variantPayment{Method: "W", Details: cardDetails{Number: "4242"}})
+	require.ErrorIs(t, err, ErrInvalidDiscriminator)
+
+	_, err = Marshal(&variantPayment{Method: "X", Details: cardDetails{Number: "4242"}})
+	require.ErrorIs(t, err, ErrUnknownVariant)
+
+	// values and pointers of the registered struct are both accepted
+	_, err = Marshal(&variantPayment{Method: "C", Details: &cardDetails{Number: "4242"}})
+	require.NoError(t, err)
+}
+
+type hookedDetails interface {
+	isHookedDetails()
+}
+
+type failingVariant struct {
+	Code string `range:"0,2"`
+}
+
+func (failingVariant) isHookedDetails() {}
+
+func (*failingVariant) BeforeMarshal() error  { return errors.New("no") }
+func (*failingVariant) AfterUnmarshal() error { return errors.New("no") }
+
+func TestVariantHookPath(t *testing.T) {
+	RegisterVariants(map[string]hookedDetails{"F": failingVariant{}})
+
+	type record struct {
+		Kind    string        `range:"2,3"`
+		Details hookedDetails `range:"3,5" discriminator:"Kind"`
+	}
+
+	var hookErr HookError
+	_, err := Marshal(&record{Kind: "F", Details: failingVariant{Code: "AB"}})
+	require.ErrorAs(t, err, &hookErr)
+	require.Equal(t, "record.Details", hookErr.Path)
+
+	err = Unmarshal([]byte("01FAB"), &record{})
+	require.ErrorAs(t, err, &hookErr)
+	require.Equal(t, "record.Details", hookErr.Path)
+}
@@ -0,0 +1,41 @@
+package fixedlength
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		name  string
+		str   string
+		width int
+	}{
+		{name: "ascii", str: "abc", width: 3},
+		{name: "kanji", str: "日本", width: 4},
+		{name: "fullwidth latin", str: "ＡＢ", width: 4},
+		{name: "halfwidth katakana", str: "ｱｲ", width: 2},
+		{name: "combining mark", str: "é", width: 1},
+		{name: "hangul", str: "한", width: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.width, DisplayWidth(tt.str))
+		})
+	}
+}
+
+func TestFormatStringWithDisplayWidth(t *testing.T) {
+	res, err := FormatStringWithDisplayWidth("日本", 6, AlignmentTypeLeft)
+	assert.NoError(t, err)
+	assert.Equal(t, "日本  ", res)
+
+	res, err = FormatStringWithDisplayWidth("日本", 7, AlignmentTypeRight)
+	assert.NoError(t, err)
+	assert.Equal(t, "   日本", res)
+
+	_, err = FormatStringWithDisplayWidth("日本語", 5, AlignmentTypeLeft)
+	assert.Error(t, err)
+}